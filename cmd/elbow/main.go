@@ -20,10 +20,21 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
+	// Embed the time zone database so that the timezone setting works on
+	// systems without one installed (e.g., Windows).
+	_ "time/tzdata"
+
+	"github.com/atc0005/elbow/internal/actions"
 	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/hooks"
+	"github.com/atc0005/elbow/internal/interactive"
+	"github.com/atc0005/elbow/internal/journal"
 	"github.com/atc0005/elbow/internal/matches"
 	"github.com/atc0005/elbow/internal/paths"
+	"github.com/atc0005/elbow/internal/priority"
+	"github.com/atc0005/elbow/internal/quarantine"
 	"github.com/atc0005/elbow/internal/units"
 
 	// Use `log` if we are going to override the default `log`, otherwise
@@ -32,7 +43,25 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// Exit codes returned by this application. Other issues encountered while
+// processing paths are reported via the log.
+const (
+	exitCodeOK           int = 0
+	exitCodeGuardTripped int = 3
+	exitCodeNotTerminal  int = 4
+)
+
 func main() {
+	os.Exit(run())
+}
+
+// run evaluates (and optionally prunes) the requested paths and returns the
+// exit code for the application. Deferred cleanup is completed before the
+// exit code is returned.
+func run() int {
+
+	// Set if a safety limit prevented pruning a path.
+	exitCode := exitCodeOK
 
 	// Checked at the end to determine if any non-fatal issues were
 	// encountered during app run
@@ -76,25 +105,252 @@ func main() {
 		}()
 	}
 
-	fileAgeThreshold := matches.NewFileAgeThreshold(appConfig.GetFileAge())
+	// Lower the scheduling priority as early as possible so that all work
+	// performed by this application is affected.
+	if appConfig.GetIdleIO() {
+		if err := priority.SetIdleIO(); err != nil {
+			log.WithFields(logrus.Fields{
+				"idle_io": appConfig.GetIdleIO(),
+			}).Warn("Unable to set idle I/O scheduling class: ", err)
+		}
+	}
+
+	if appConfig.GetNice() > 0 {
+		if err := priority.SetNice(appConfig.GetNice()); err != nil {
+			log.WithFields(logrus.Fields{
+				"nice": appConfig.GetNice(),
+			}).Warn("Unable to set nice value: ", err)
+		}
+	}
+
+	switch appConfig.GetSubcommand() {
+	case config.SubcommandPin, config.SubcommandUnpin:
+		if pinFiles(appConfig) {
+			log.Warnf("%s %s completed, but issues were encountered.",
+				appConfig.GetAppName(), appConfig.GetSubcommand())
+			return exitCode
+		}
+		log.Infof("%s %s successfully completed.",
+			appConfig.GetAppName(), appConfig.GetSubcommand())
+		return exitCode
+
+	case config.SubcommandResume:
+		if resumeRuns(appConfig) {
+			log.Warnf("%s %s completed, but issues were encountered.",
+				appConfig.GetAppName(), appConfig.GetSubcommand())
+			return exitCode
+		}
+		log.Infof("%s %s successfully completed.",
+			appConfig.GetAppName(), appConfig.GetSubcommand())
+		return exitCode
+
+	case config.SubcommandRestore:
+		if restoreFiles(appConfig) {
+			log.Warnf("%s %s completed, but issues were encountered.",
+				appConfig.GetAppName(), appConfig.GetSubcommand())
+			return exitCode
+		}
+		log.Infof("%s %s successfully completed.",
+			appConfig.GetAppName(), appConfig.GetSubcommand())
+		return exitCode
+	}
+
+	// Planned removals can only be confirmed by an operator at a terminal.
+	var reviewer *interactive.Reviewer
+	if appConfig.GetInteractive() {
+		if !interactive.IsTerminal(os.Stdin) {
+			log.WithFields(logrus.Fields{
+				"interactive": appConfig.GetInteractive(),
+			}).Error("error:", interactive.ErrNotTerminal)
+			log.Warn("Unable to confirm planned removals without a terminal. Exiting")
+			return exitCodeNotTerminal
+		}
+		reviewer = interactive.New(os.Stdin, os.Stderr)
+	}
+
+	fileAgeThreshold := matches.NewFileAgeThreshold(appConfig.GetFileAge(), appConfig)
 
 	log.WithFields(logrus.Fields{
-		"paths":              appConfig.GetPaths(),
-		"file_pattern":       appConfig.GetFilePattern(),
-		"extensions":         appConfig.GetFileExtensions(),
-		"file_age":           appConfig.GetFileAge(),
-		"file_age_threshold": fileAgeThreshold.FormatLog(),
+		"paths":               appConfig.GetPaths(),
+		"file_pattern":        appConfig.GetFilePattern(),
+		"extensions":          appConfig.GetFileExtensions(),
+		"content_pattern":     appConfig.GetContentPattern(),
+		"content_regex":       appConfig.GetContentRegex(),
+		"content_types":       appConfig.GetContentTypes(),
+		"file_age":            appConfig.GetFileAge(),
+		"file_age_threshold":  fileAgeThreshold.FormatLog(),
+		"calendar_age":        appConfig.GetCalendarAge(),
+		"timezone":            appConfig.GetLocation().String(),
+		"relative_age":        appConfig.GetRelativeAge(),
+		"as_of":               appConfig.GetAsOf(),
+		"max_staleness":       appConfig.GetMaxStaleness(),
+		"quarantine_dir":      appConfig.GetQuarantineDir(),
+		"compress":            appConfig.GetCompress(),
+		"shred_passes":        appConfig.GetShredPasses(),
+		"truncate":            appConfig.GetTruncate(),
+		"truncate_open":       appConfig.GetTruncateOpen(),
+		"exec_command":        appConfig.GetExecCommand(),
+		"workers":             appConfig.GetWorkers(),
+		"delete_rate":         appConfig.GetDeleteRate(),
+		"delete_bytes_rate":   appConfig.GetDeleteBytesRate(),
+		"stat_rate":           appConfig.GetStatRate(),
+		"idle_io":             appConfig.GetIdleIO(),
+		"nice":                appConfig.GetNice(),
+		"max_removals":        appConfig.GetMaxRemovals(),
+		"max_removal_size":    appConfig.GetMaxRemovalSize(),
+		"max_removal_percent": appConfig.GetMaxRemovalPercent(),
+		"keep_last_match":     appConfig.GetKeepLastMatch(),
+		"force":               appConfig.GetForce(),
+		"stages":              len(appConfig.Stages),
+		"pre_hook":            appConfig.GetPreHook(),
+		"post_hook":           appConfig.GetPostHook(),
+		"journal_dir":         appConfig.GetJournalDir(),
+		"interactive":         appConfig.GetInteractive(),
+		"run_id":              appConfig.GetRunID(),
 	}).Info("Starting evaluation of paths list")
 
+	if appConfig.GetAsOf() != "" && appConfig.GetRemove() {
+		log.WithFields(logrus.Fields{
+			"as_of": appConfig.GetAsOf(),
+		}).Warn("File age is evaluated as of the specified time instead of the current time; matching files will be removed")
+	}
+
+	// A safety limit exceeded by any path aborts the run if the guard scope
+	// is set to run, so all paths are evaluated before anything is removed.
+	if appConfig.GetGuardScope() == config.GuardScopeRun && !appConfig.GetForce() {
+		if path, err := paths.CheckRunGuards(appConfig, appConfig.GetPaths()); err != nil {
+
+			log.WithFields(logrus.Fields{
+				"path":        path,
+				"guard_scope": appConfig.GetGuardScope(),
+			}).Errorf("Safety limit exceeded for path %q: %s", path, err)
+
+			log.WithFields(logrus.Fields{
+				"guard_scope": appConfig.GetGuardScope(),
+			}).Warn("Safety limit exceeded and guard scope set to run. Exiting before pruning any path")
+			return exitCodeGuardTripped
+		}
+	}
+
 	// Used as a global counter/bucket for presentation/logging purposes
 	var appResults paths.ProcessingResults
 
+	// Used to report the runtime, including any time spent waiting on rate
+	// limits.
+	started := time.Now()
+
+	appResults.Stages = make([]paths.StageResults, len(appConfig.Stages))
+	for i, stage := range appConfig.Stages {
+		appResults.Stages[i].Stage = stage
+	}
+
+	if appConfig.GetQuarantineDir() != "" && appConfig.GetQuarantineRetention() > 0 {
+		purgedRuns, err := quarantine.Purge(appConfig)
+		if err != nil {
+
+			// checked at end of application run for summary report
+			problemsEncountered = true
+
+			log.WithFields(logrus.Fields{
+				"quarantine_dir": appConfig.GetQuarantineDir(),
+				"ignore_errors":  appConfig.GetIgnoreErrors(),
+			}).Error("error:", err)
+
+			if !appConfig.GetIgnoreErrors() {
+				log.WithFields(logrus.Fields{
+					"ignore_errors": appConfig.GetIgnoreErrors(),
+				}).Warn("Error encountered and option to ignore errors not set. Exiting")
+				return exitCode
+			}
+			log.Warn("Error encountered, but continuing as requested.")
+		}
+
+		if !appConfig.GetRemove() && len(purgedRuns) > 0 {
+			log.Info("File removal not enabled, not purging quarantine runs")
+		}
+
+		for _, run := range purgedRuns {
+			log.WithFields(logrus.Fields{
+				"quarantine_dir":       appConfig.GetQuarantineDir(),
+				"quarantine_retention": appConfig.GetQuarantineRetention(),
+				"removal_enabled":      appConfig.GetRemove(),
+			}).Infof("Quarantine run %s eligible for purging", run)
+		}
+		appResults.PurgedRuns += len(purgedRuns)
+	}
+
+	// File state recorded by the previous run, used to skip files that are
+	// still being written.
+	var stabilityState *matches.StabilityState
+	if appConfig.GetStabilityStateFile() != "" {
+		state, err := matches.LoadStabilityState(appConfig.GetStabilityStateFile())
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"stability_state_file": appConfig.GetStabilityStateFile(),
+			}).Error("error:", err)
+			log.Warn("Unable to confirm file stability without state file. Exiting")
+			return exitCode
+		}
+		stabilityState = &state
+
+		defer func() {
+			if err := stabilityState.Save(appConfig.GetStabilityStateFile()); err != nil {
+				log.WithFields(logrus.Fields{
+					"stability_state_file": appConfig.GetStabilityStateFile(),
+				}).Error("error:", err)
+			}
+		}()
+	}
+
+	// Record the files planned for removal and the outcome of handling each
+	// of them so that the run can be resumed if interrupted.
+	if appConfig.GetJournalDir() != "" && appConfig.GetRemove() {
+		settings, err := appConfig.Snapshot()
+		var runJournal *journal.Journal
+		if err == nil {
+			runJournal, err = journal.Create(appConfig.GetJournalDir(), appConfig.GetRunID(), settings)
+		}
+
+		if err != nil {
+
+			// checked at end of application run for summary report
+			problemsEncountered = true
+
+			log.WithFields(logrus.Fields{
+				"journal_dir":   appConfig.GetJournalDir(),
+				"ignore_errors": appConfig.GetIgnoreErrors(),
+			}).Error("error:", err)
+
+			if !appConfig.GetIgnoreErrors() {
+				log.WithFields(logrus.Fields{
+					"ignore_errors": appConfig.GetIgnoreErrors(),
+				}).Warn("Error encountered and option to ignore errors not set. Exiting")
+				return exitCode
+			}
+			log.Warn("Error encountered, but continuing as requested.")
+		}
+
+		// Runs which exit early (e.g., due to an error) are left incomplete
+		// so that they can be resumed.
+		appConfig.SetJournal(runJournal)
+		defer func() {
+			if err := runJournal.Close(); err != nil {
+				log.WithFields(logrus.Fields{
+					"journal_dir": appConfig.GetJournalDir(),
+				}).Error("error:", err)
+			}
+		}()
+	}
+
 	var pass int
 	var totalPaths = len(appConfig.GetPaths())
 	for _, path := range appConfig.GetPaths() {
 
 		pass++
 
+		// Apply any settings specific to this path.
+		pathConfig := appConfig.ForPath(path)
+
 		log.WithFields(logrus.Fields{
 			"total_paths":   totalPaths,
 			"iteration":     pass,
@@ -117,7 +373,7 @@ func main() {
 				log.WithFields(logrus.Fields{
 					"ignore_errors": appConfig.GetIgnoreErrors(),
 				}).Warn("Error encountered and option to ignore errors not set. Exiting")
-				return
+				return exitCode
 			}
 			log.Warn("Error encountered, but continuing as requested.")
 		}
@@ -140,11 +396,31 @@ func main() {
 					"ignore_errors": appConfig.GetIgnoreErrors(),
 				}).Warn("Error encountered and option to ignore errors not set. Exiting")
 
-				return
+				return exitCode
 			}
 		}
 
-		fileMatches, err := paths.ProcessPath(appConfig, path)
+		fileMatches, totalFiles, err := paths.ProcessPath(pathConfig, path)
+		if errors.Is(err, paths.ErrStalePath) {
+
+			// checked at end of application run for summary report
+			problemsEncountered = true
+
+			log.WithFields(logrus.Fields{
+				"path":          path,
+				"max_staleness": appConfig.GetMaxStaleness(),
+				"iteration":     pass,
+			}).Warnf("Skipping pruning of path %q: %s", path, err)
+
+			log.WithFields(logrus.Fields{
+				"total_paths":   totalPaths,
+				"iteration":     pass,
+				"ignore_errors": appConfig.GetIgnoreErrors(),
+			}).Infof("Ending processing of path %q (%d of %d)",
+				path, pass, totalPaths)
+			continue
+		}
+
 		if err != nil {
 
 			// checked at end of application run for summary report
@@ -159,7 +435,7 @@ func main() {
 				log.WithFields(logrus.Fields{
 					"ignore_errors": appConfig.GetIgnoreErrors(),
 				}).Warn("Error encountered and option to ignore errors not set. Exiting")
-				return
+				return exitCode
 			}
 			log.Warn("Error encountered, but continuing as requested.")
 		}
@@ -191,6 +467,37 @@ func main() {
 
 		}
 
+		if appConfig.GetProtectSymlinkTargets() {
+			numProtected, err := paths.ProtectSymlinkTargets(appConfig, path, fileMatches)
+			if err != nil {
+
+				// checked at end of application run for summary report
+				problemsEncountered = true
+
+				log.WithFields(logrus.Fields{
+					"ignore_errors": appConfig.GetIgnoreErrors(),
+					"iteration":     pass,
+				}).Error("error:", err)
+
+				if !appConfig.GetIgnoreErrors() {
+					log.WithFields(logrus.Fields{
+						"ignore_errors": appConfig.GetIgnoreErrors(),
+					}).Warn("Error encountered and option to ignore errors not set. Exiting")
+					return exitCode
+				}
+
+				// Pruning without knowing which files are symlink targets
+				// could remove a file we were asked to protect.
+				log.Warnf("Unable to determine symlink targets, skipping path %q", path)
+				continue
+			}
+
+			log.WithFields(logrus.Fields{
+				"symlink_locations": appConfig.GetSymlinkLocations(),
+				"iteration":         pass,
+			}).Debugf("%d matching files are symlink targets", numProtected)
+		}
+
 		log.WithFields(logrus.Fields{
 			"path":               path,
 			"file_pattern":       appConfig.GetFilePattern(),
@@ -206,6 +513,22 @@ func main() {
 		appResults.EligibleRemove += len(fileMatches)
 		appResults.EligibleFileSize += fileMatches.TotalFileSize()
 
+		protectedFiles := fileMatches.ProtectedFiles()
+		appResults.Protected += len(protectedFiles)
+
+		if len(protectedFiles) > 0 {
+			log.WithFields(logrus.Fields{
+				"iteration": pass,
+			}).Infof("%d files protected from removal", len(protectedFiles))
+			for _, file := range protectedFiles {
+				log.WithFields(logrus.Fields{
+					"protected_reason": file.ProtectedReason,
+					"file_size":        file.SizeHR(),
+					"iteration":        pass,
+				}).Info(matches.DisplayName(file.Path))
+			}
+		}
+
 		log.WithFields(logrus.Fields{
 			"keep_oldest": appConfig.GetKeepOldest(),
 			"iteration":   pass,
@@ -213,6 +536,32 @@ func main() {
 
 		filesToPrune := fileMatches.FilesToPrune(appConfig)
 
+		if appConfig.GetSettleSeconds() > 0 || stabilityState != nil {
+			var unstableFiles matches.FileMatches
+			filesToPrune, unstableFiles = filesToPrune.CheckStability(appConfig, stabilityState)
+
+			appResults.Skipped += len(unstableFiles)
+
+			if len(unstableFiles) > 0 {
+				log.WithFields(logrus.Fields{
+					"iteration": pass,
+				}).Infof("%d files skipped by write-stability check", len(unstableFiles))
+				for _, file := range unstableFiles {
+					log.WithFields(logrus.Fields{
+						"skip_reason": file.SkipReason,
+						"file_size":   file.SizeHR(),
+						"iteration":   pass,
+					}).Info(matches.DisplayName(file.Path))
+				}
+			}
+
+			// Record the current state of all matches for comparison by the
+			// next run.
+			if stabilityState != nil {
+				stabilityState.Record(fileMatches)
+			}
+		}
+
 		if len(filesToPrune) == 0 {
 			log.Info("Nothing to prune")
 			log.WithFields(logrus.Fields{
@@ -227,41 +576,256 @@ func main() {
 			continue
 		}
 
+		if err := paths.CheckGuards(pathConfig, filesToPrune, fileMatches, totalFiles); err != nil {
+
+			if !appConfig.GetForce() {
+
+				// checked at end of application run for summary report
+				problemsEncountered = true
+
+				exitCode = exitCodeGuardTripped
+				appResults.GuardedPaths++
+
+				log.WithFields(logrus.Fields{
+					"path":            path,
+					"files_to_prune":  len(filesToPrune),
+					"total_file_size": filesToPrune.TotalFileSizeHR(),
+					"total_files":     totalFiles,
+					"guard_scope":     appConfig.GetGuardScope(),
+					"iteration":       pass,
+				}).Errorf("Skipping pruning of path %q: %s", path, err)
+
+				if appConfig.GetGuardScope() == config.GuardScopeRun {
+					log.WithFields(logrus.Fields{
+						"guard_scope": appConfig.GetGuardScope(),
+					}).Warn("Safety limit exceeded and guard scope set to run. Exiting")
+					return exitCode
+				}
+
+				log.WithFields(logrus.Fields{
+					"total_paths":   totalPaths,
+					"iteration":     pass,
+					"ignore_errors": appConfig.GetIgnoreErrors(),
+				}).Infof("Ending processing of path %q (%d of %d)",
+					path, pass, totalPaths)
+				continue
+			}
+
+			log.WithFields(logrus.Fields{
+				"path":      path,
+				"force":     appConfig.GetForce(),
+				"iteration": pass,
+			}).Warnf("Pruning path %q despite exceeded safety limit as forced: %s", path, err)
+		}
+
+		if reviewer != nil && pathConfig.GetRemove() {
+			confirmed, err := reviewer.Review(pathConfig, path, filesToPrune)
+			if err != nil {
+
+				// checked at end of application run for summary report
+				problemsEncountered = true
+
+				if errors.Is(err, interactive.ErrAborted) {
+					log.WithFields(logrus.Fields{
+						"path":      path,
+						"iteration": pass,
+					}).Warn("Run aborted during review of planned removals. Exiting")
+					return exitCode
+				}
+
+				log.WithFields(logrus.Fields{
+					"path":      path,
+					"iteration": pass,
+				}).Error("error:", err)
+				log.Warn("Unable to confirm planned removals. Exiting")
+				return exitCode
+			}
+
+			log.WithFields(logrus.Fields{
+				"path":       path,
+				"confirmed":  len(confirmed),
+				"deselected": len(filesToPrune) - len(confirmed),
+				"iteration":  pass,
+			}).Infof("Removal of %d of %d planned files confirmed",
+				len(confirmed), len(filesToPrune))
+
+			filesToPrune = confirmed
+
+			if len(filesToPrune) == 0 {
+				log.Info("Nothing to prune")
+				log.WithFields(logrus.Fields{
+					"total_paths":   totalPaths,
+					"iteration":     pass,
+					"ignore_errors": appConfig.GetIgnoreErrors(),
+				}).Infof("Ending processing of path %q (%d of %d)",
+					path, pass, totalPaths)
+				if pass < totalPaths {
+					log.Debugf("Continuing to next available path")
+				}
+				continue
+			}
+		}
+
+		if pathConfig.GetPreHook() != "" {
+			if !pathConfig.GetRemove() {
+				log.WithFields(logrus.Fields{
+					"pre_hook":  pathConfig.GetPreHook(),
+					"iteration": pass,
+				}).Infof("Dry run: would run pre hook for path %q", path)
+			} else if err := hooks.RunPre(pathConfig, path); err != nil {
+
+				// checked at end of application run for summary report
+				problemsEncountered = true
+
+				appResults.VetoedPaths++
+
+				log.WithFields(logrus.Fields{
+					"path":      path,
+					"iteration": pass,
+				}).Warnf("Skipping pruning of path %q, vetoed by pre hook: %s", path, err)
+
+				log.WithFields(logrus.Fields{
+					"total_paths":   totalPaths,
+					"iteration":     pass,
+					"ignore_errors": appConfig.GetIgnoreErrors(),
+				}).Infof("Ending processing of path %q (%d of %d)",
+					path, pass, totalPaths)
+				continue
+			}
+		}
+
+		if len(pathConfig.Stages) > 0 {
+
+			// Combined results of all stages, provided to the post hook.
+			var pathResults paths.PathPruningResults
+
+			stagedFiles, unstagedFiles := paths.AssignStages(path, filesToPrune, pathConfig)
+
+			log.WithFields(logrus.Fields{
+				"iteration": pass,
+			}).Infof("%d files not yet due for any lifecycle stage", len(unstagedFiles))
+
+			for i, staged := range stagedFiles {
+
+				log.WithFields(logrus.Fields{
+					"stage":       staged.Stage.Action,
+					"stage_age":   staged.Stage.Age,
+					"destination": staged.Config.GetMoveDir(),
+					"compress":    staged.Config.GetCompress(),
+					"iteration":   pass,
+				}).Infof("%d files due for lifecycle stage %q (%s)",
+					len(staged.Files),
+					staged.Stage.Action,
+					staged.Files.TotalFileSizeHR())
+
+				for _, file := range staged.Files {
+					log.WithFields(logrus.Fields{
+						"stage":     staged.Stage.Action,
+						"file_size": file.SizeHR(),
+						"iteration": pass,
+					}).Info(matches.DisplayName(file.Path))
+				}
+
+				if len(staged.Files) == 0 {
+					continue
+				}
+
+				stage := i
+				if err := paths.RecordPlan(staged.Config, path, &stage, staged.Files); err != nil {
+
+					// checked at end of application run for summary report
+					problemsEncountered = true
+
+					log.WithFields(logrus.Fields{
+						"ignore_errors": appConfig.GetIgnoreErrors(),
+						"iteration":     pass,
+					}).Error("error:", err)
+
+					// Files may not be handled without recording the plan
+					// first as the run could not be resumed.
+					log.Warn("Unable to record planned files in journal. Exiting")
+					return exitCode
+				}
+
+				stageResults, err := paths.CleanPath(staged.Files, staged.Config)
+
+				appResults.Stages[i].Add(stageResults)
+				appResults.Add(stageResults)
+				pathResults.Add(stageResults)
+
+				logPruningResults(log, stageResults, pass)
+
+				if err == nil && len(stageResults.FailedRemovals) > 0 && !appConfig.GetIgnoreErrors() {
+					err = fmt.Errorf("%d files failed lifecycle stage %q",
+						len(stageResults.FailedRemovals), staged.Stage.Action)
+				}
+
+				if err != nil {
+
+					// checked at end of application run for summary report
+					problemsEncountered = true
+
+					log.Warnf("Error encountered while processing %s: %s", path, err)
+
+					if !appConfig.GetIgnoreErrors() {
+						runPostHook(pathConfig, path, pathResults, pass)
+						log.WithFields(logrus.Fields{
+							"ignore_errors": appConfig.GetIgnoreErrors(),
+							"iteration":     pass,
+						}).Warn("Error encountered and option to ignore errors not set. Exiting")
+						return exitCode
+					}
+					log.Warn("Error encountered, but continuing as requested.")
+				}
+			}
+
+			if !runPostHook(pathConfig, path, pathResults, pass) {
+				// checked at end of application run for summary report
+				problemsEncountered = true
+			}
+
+			log.WithFields(logrus.Fields{
+				"total_paths":   totalPaths,
+				"iteration":     pass,
+				"ignore_errors": appConfig.GetIgnoreErrors(),
+			}).Infof("Ending processing of path %q (%d of %d)",
+				path, pass, totalPaths)
+
+			continue
+		}
+
 		log.WithFields(logrus.Fields{
 			"files_to_prune":  len(filesToPrune),
 			"total_file_size": filesToPrune.TotalFileSizeHR(),
 			"iteration":       pass,
 		}).Debug("Calling cleanPath")
 		log.Infof("Ignoring file removal errors: %t", appConfig.GetIgnoreErrors())
-		removalResults, err := paths.CleanPath(filesToPrune, appConfig)
-
-		appResults.SuccessRemoved += len(removalResults.SuccessfulRemovals)
-		appResults.SuccessTotalFileSize += removalResults.SuccessfulRemovals.TotalFileSize()
-		appResults.FailedRemoved += len(removalResults.FailedRemovals)
-		appResults.FailedTotalFileSize += removalResults.FailedRemovals.TotalFileSize()
-
-		// Show what we WERE able to successfully remove
-		// TODO: Refactor this into a function to handle displaying results?
-		log.Infof("%d files successfully removed (%s)",
-			len(removalResults.SuccessfulRemovals),
-			removalResults.SuccessfulRemovals.TotalFileSizeHR())
-		for _, file := range removalResults.SuccessfulRemovals {
+
+		if err := paths.RecordPlan(pathConfig, path, nil, filesToPrune); err != nil {
+
+			// checked at end of application run for summary report
+			problemsEncountered = true
+
 			log.WithFields(logrus.Fields{
-				"failed_removal": false,
-				"file_size":      file.SizeHR(),
-				"iteration":      pass,
-			}).Info(file.Path)
+				"ignore_errors": appConfig.GetIgnoreErrors(),
+				"iteration":     pass,
+			}).Error("error:", err)
+
+			// Files may not be handled without recording the plan first as
+			// the run could not be resumed.
+			log.Warn("Unable to record planned files in journal. Exiting")
+			return exitCode
 		}
 
-		log.Infof("%d files failed to remove (%s)",
-			len(removalResults.FailedRemovals),
-			removalResults.FailedRemovals.TotalFileSizeHR())
-		for _, file := range removalResults.FailedRemovals {
-			log.WithFields(logrus.Fields{
-				"failed_removal": true,
-				"file_size":      file.SizeHR(),
-				"iteration":      pass,
-			}).Info(file.Path)
+		removalResults, err := paths.CleanPath(filesToPrune, pathConfig)
+
+		appResults.Add(removalResults)
+
+		logPruningResults(log, removalResults, pass)
+
+		if !runPostHook(pathConfig, path, removalResults, pass) {
+			// checked at end of application run for summary report
+			problemsEncountered = true
 		}
 
 		// this is the error checking for paths.CleanPath()
@@ -277,7 +841,7 @@ func main() {
 					"ignore_errors": appConfig.GetIgnoreErrors(),
 					"iteration":     pass,
 				}).Warn("Error encountered and option to ignore errors not set. Exiting")
-				return
+				return exitCode
 			}
 			log.Warn("Error encountered, but continuing as requested.")
 			continue
@@ -292,6 +856,16 @@ func main() {
 
 	}
 
+	if err := appConfig.GetJournal().Complete(); err != nil {
+
+		// checked at end of application run for summary report
+		problemsEncountered = true
+
+		log.WithFields(logrus.Fields{
+			"journal_dir": appConfig.GetJournalDir(),
+		}).Error("error:", err)
+	}
+
 	// Configure fields for execution summary results
 	summaryLogger := log.WithFields(logrus.Fields{
 		"success_removed": appResults.SuccessRemoved,
@@ -300,6 +874,15 @@ func main() {
 		"failed_size":     units.ByteCountIEC(appResults.FailedTotalFileSize),
 		"eligible_remove": appResults.EligibleRemove,
 		"eligible_size":   units.ByteCountIEC(appResults.EligibleFileSize),
+		"protected":       appResults.Protected,
+		"skipped":         appResults.Skipped,
+		"purged_runs":     appResults.PurgedRuns,
+		"vetoed_paths":    appResults.VetoedPaths,
+		"guarded_paths":   appResults.GuardedPaths,
+		"modified":        appResults.Modified,
+		"saved_size":      units.ByteCountIEC(appResults.SavedFileSize),
+		"runtime":         time.Since(started).Round(time.Millisecond).String(),
+		"throttled_time":  appConfig.GetThrottle().Waited().Round(time.Millisecond).String(),
 
 		// Not sure this "adds" anything to the summary and could be confusing
 		// "total_processed": appResults.FailedRemoved + appResults.SuccessRemoved,
@@ -307,10 +890,131 @@ func main() {
 
 	})
 
+	for _, actionResults := range appResults.Actions {
+		log.WithFields(logrus.Fields{
+			"action":         actionResults.Action,
+			"succeeded":      actionResults.Succeeded,
+			"succeeded_size": units.ByteCountIEC(actionResults.SucceededFileSize),
+			"failed":         actionResults.Failed,
+			"skipped":        actionResults.Skipped,
+			"saved_size":     units.ByteCountIEC(actionResults.SavedFileSize),
+		}).Infof("Action %q summary", actionResults.Action)
+	}
+
+	for _, stageResults := range appResults.Stages {
+		log.WithFields(logrus.Fields{
+			"stage":          stageResults.Stage.Action,
+			"stage_age":      stageResults.Stage.Age,
+			"processed":      stageResults.Processed,
+			"processed_size": units.ByteCountIEC(stageResults.ProcessedFileSize),
+			"failed":         stageResults.Failed,
+			"skipped":        stageResults.Skipped,
+			"saved_size":     units.ByteCountIEC(stageResults.SavedFileSize),
+		}).Infof("Lifecycle stage %q summary", stageResults.Stage.Action)
+	}
+
 	if problemsEncountered {
 		summaryLogger.Warnf("%s completed, but issues were encountered.", appConfig.GetAppName())
 	} else {
 		summaryLogger.Infof("%s successfully completed.", appConfig.GetAppName())
 	}
 
+	return exitCode
+}
+
+// logPruningResults logs the files successfully removed, modified in place,
+// skipped and failed for a single path, along with the action applied to
+// each file.
+func logPruningResults(log *logrus.Logger, results paths.PathPruningResults, pass int) {
+
+	logOutcomes := func(include func(actions.Outcome) bool) {
+		for _, outcome := range results.Outcomes {
+			if !include(outcome) {
+				continue
+			}
+
+			fields := logrus.Fields{
+				"action":         outcome.Action,
+				"failed_removal": outcome.Status == actions.Failed,
+				"file_size":      outcome.File.SizeHR(),
+				"iteration":      pass,
+			}
+			if outcome.Destination != "" {
+				fields["destination"] = matches.DisplayName(outcome.Destination)
+			}
+			if outcome.Saved != 0 {
+				fields["saved_size"] = units.ByteCountIEC(outcome.Saved)
+			}
+			if outcome.SkipReason != "" {
+				fields["skip_reason"] = outcome.SkipReason
+			}
+			if outcome.RollbackHint != "" {
+				fields["rollback_hint"] = outcome.RollbackHint
+			}
+
+			log.WithFields(fields).Info(matches.DisplayName(outcome.File.Path))
+		}
+	}
+
+	// Show what we WERE able to successfully remove
+	log.Infof("%d files successfully removed (%s)",
+		len(results.SuccessfulRemovals),
+		results.SuccessfulRemovals.TotalFileSizeHR())
+	logOutcomes(func(outcome actions.Outcome) bool {
+		return outcome.Status == actions.Succeeded && outcome.Removed
+	})
+
+	if len(results.Modified) > 0 {
+		log.Infof("%d files modified in place instead of removed (%s saved)",
+			len(results.Modified),
+			units.ByteCountIEC(results.SavedFileSize()))
+		logOutcomes(func(outcome actions.Outcome) bool {
+			return outcome.Status == actions.Succeeded && !outcome.Removed
+		})
+	}
+
+	if len(results.Skipped) > 0 {
+		log.Infof("%d files skipped (%s)",
+			len(results.Skipped),
+			results.Skipped.TotalFileSizeHR())
+		logOutcomes(func(outcome actions.Outcome) bool {
+			return outcome.Status == actions.Skipped
+		})
+	}
+
+	log.Infof("%d files failed to remove (%s)",
+		len(results.FailedRemovals),
+		results.FailedRemovals.TotalFileSizeHR())
+	logOutcomes(func(outcome actions.Outcome) bool {
+		return outcome.Status == actions.Failed
+	})
+}
+
+// runPostHook runs the post hook for the path, if configured, providing the
+// results of pruning the path. Any error encountered is logged. true is
+// returned if the hook is not configured or completed successfully.
+func runPostHook(c *config.Config, path string, results paths.PathPruningResults, pass int) bool {
+
+	log := c.GetLogger()
+
+	switch {
+	case c.GetPostHook() == "":
+		return true
+	case !c.GetRemove():
+		log.WithFields(logrus.Fields{
+			"post_hook": c.GetPostHook(),
+			"iteration": pass,
+		}).Infof("Dry run: would run post hook for path %q", path)
+		return true
+	}
+
+	if err := hooks.RunPost(c, path, results); err != nil {
+		log.WithFields(logrus.Fields{
+			"path":      path,
+			"iteration": pass,
+		}).Error("error:", err)
+		return false
+	}
+
+	return true
 }
@@ -0,0 +1,81 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/sirupsen/logrus"
+)
+
+// pinFiles handles the pin and unpin subcommands by setting or removing the
+// pin extended attribute on each requested file. The return value indicates
+// whether any problems were encountered.
+func pinFiles(appConfig *config.Config) bool {
+
+	log := appConfig.GetLogger()
+
+	problemsEncountered := false
+
+	var files []string
+	var until time.Time
+
+	switch appConfig.GetSubcommand() {
+	case config.SubcommandPin:
+		files = appConfig.Pin.Files
+		if appConfig.Pin.Until != "" {
+			// Already validated as part of config validation.
+			until, _ = time.Parse(time.RFC3339, appConfig.Pin.Until)
+		}
+	case config.SubcommandUnpin:
+		files = appConfig.Unpin.Files
+	}
+
+	for _, file := range files {
+
+		contextLogger := log.WithFields(logrus.Fields{
+			"file":          file,
+			"pin_attribute": appConfig.GetPinAttribute(),
+			"subcommand":    appConfig.GetSubcommand(),
+		})
+
+		var err error
+		switch appConfig.GetSubcommand() {
+		case config.SubcommandPin:
+			err = matches.Pin(file, appConfig.GetPinAttribute(), until)
+		case config.SubcommandUnpin:
+			err = matches.Unpin(file, appConfig.GetPinAttribute())
+		}
+
+		if err != nil {
+			problemsEncountered = true
+			contextLogger.Errorf("Failed to update pin attribute: %s", err)
+			continue
+		}
+
+		if appConfig.GetSubcommand() == config.SubcommandPin && !until.IsZero() {
+			contextLogger.Infof("Pinned %q until %s", file, until.Format(time.RFC3339))
+			continue
+		}
+
+		contextLogger.Infof("Completed %s of %q", appConfig.GetSubcommand(), file)
+	}
+
+	return problemsEncountered
+}
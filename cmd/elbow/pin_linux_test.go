@@ -0,0 +1,80 @@
+//go:build linux
+// +build linux
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"golang.org/x/sys/unix"
+)
+
+func TestPinFiles(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("pin test\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	until := time.Date(2026, time.January, 2, 15, 4, 5, 0, time.UTC)
+
+	c := config.NewDefaultConfig()
+	*c.AsOf = "2026-01-01T00:00:00Z"
+	c.GetLogger().SetOutput(io.Discard)
+
+	// Extended attributes are not supported by all filesystems.
+	err := matches.Unpin(path, c.GetPinAttribute())
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		t.Skipf("extended attributes not supported: %s", err)
+	}
+
+	c.Pin = &config.PinCmd{Until: until.Format(time.RFC3339), Files: []string{path}}
+
+	if pinFiles(&c) {
+		t.Fatalf("pinFiles() encountered problems pinning %s", path)
+	}
+
+	if !matches.IsPinned(path, &c) {
+		t.Errorf("%s not pinned before %s", path, until)
+	}
+
+	*c.AsOf = "2026-01-03T00:00:00Z"
+	if matches.IsPinned(path, &c) {
+		t.Errorf("%s still pinned after %s", path, until)
+	}
+
+	c.Pin = nil
+	c.Unpin = &config.UnpinCmd{Files: []string{path}}
+
+	if pinFiles(&c) {
+		t.Fatalf("pinFiles() encountered problems unpinning %s", path)
+	}
+
+	*c.AsOf = "2026-01-01T00:00:00Z"
+	if matches.IsPinned(path, &c) {
+		t.Errorf("%s still pinned after unpin", path)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/quarantine"
+	"github.com/sirupsen/logrus"
+)
+
+// restoreFiles handles the restore subcommand by moving the requested
+// quarantined files back to their original location. The return value
+// indicates whether any problems were encountered.
+func restoreFiles(appConfig *config.Config) bool {
+
+	log := appConfig.GetLogger()
+
+	results, err := quarantine.Restore(
+		appConfig,
+		appConfig.Restore.Runs,
+		appConfig.Restore.Files,
+	)
+
+	for _, entry := range results.Restored {
+		log.WithFields(logrus.Fields{
+			"run_id":         entry.RunID,
+			"failed_restore": false,
+		}).Info(matches.DisplayName(entry.OriginalPath))
+	}
+
+	for _, entry := range results.Failed {
+		log.WithFields(logrus.Fields{
+			"run_id":         entry.RunID,
+			"failed_restore": true,
+		}).Info(matches.DisplayName(entry.OriginalPath))
+	}
+
+	log.WithFields(logrus.Fields{
+		"quarantine_dir":  appConfig.GetQuarantineDir(),
+		"runs":            appConfig.Restore.Runs,
+		"files":           appConfig.Restore.Files,
+		"success_restore": len(results.Restored),
+		"failed_restore":  len(results.Failed),
+	}).Infof("%d files restored, %d files failed to restore",
+		len(results.Restored), len(results.Failed))
+
+	if err != nil {
+		log.Error("error:", err)
+		return true
+	}
+
+	return len(results.Failed) > 0
+}
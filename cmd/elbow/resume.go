@@ -0,0 +1,245 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/hooks"
+	"github.com/atc0005/elbow/internal/journal"
+	"github.com/atc0005/elbow/internal/paths"
+	"github.com/atc0005/elbow/internal/units"
+	"github.com/sirupsen/logrus"
+)
+
+// resumeRuns handles the resume subcommand by finishing the requested
+// interrupted runs (or all interrupted runs recorded in the journal
+// directory) exactly as planned. The return value indicates whether any
+// problems were encountered.
+func resumeRuns(appConfig *config.Config) bool {
+
+	log := appConfig.GetLogger()
+
+	runIDs := appConfig.Resume.Runs
+	if len(runIDs) == 0 {
+		var err error
+		runIDs, err = journal.Incomplete(appConfig.GetJournalDir())
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"journal_dir": appConfig.GetJournalDir(),
+			}).Error("error:", err)
+			return true
+		}
+	}
+
+	if len(runIDs) == 0 {
+		log.WithFields(logrus.Fields{
+			"journal_dir": appConfig.GetJournalDir(),
+		}).Info("No interrupted runs found")
+		return false
+	}
+
+	var problemsEncountered bool
+
+	for _, runID := range runIDs {
+		if resumeRun(appConfig, runID) {
+			problemsEncountered = true
+		}
+	}
+
+	return problemsEncountered
+}
+
+// resumeRun finishes a single interrupted run, handling the files planned by
+// the run which it did not handle. The settings recorded for the run are
+// used and each file is confirmed to still be the file found by the run
+// before it is handled. The pre hook is run again for each path with files
+// not yet handled; paths vetoed by the pre hook are left for a later resume
+// and the run remains resumable. Safety limits are not applied again as they
+// already applied to the planned files. Post hooks are run for each resumed
+// path. The return value indicates whether any problems were encountered.
+func resumeRun(appConfig *config.Config, runID string) bool {
+
+	log := appConfig.GetLogger().WithFields(logrus.Fields{
+		"run_id": runID,
+	})
+
+	run, err := journal.Load(appConfig.GetJournalDir(), runID)
+	if err != nil {
+		log.Error("error:", err)
+		return true
+	}
+
+	if run.Complete {
+		log.Infof("Run %s already completed, nothing to resume", runID)
+		return false
+	}
+
+	runConfig, err := appConfig.ForResume(runID, run.Config)
+	if err != nil {
+		log.Error("error:", err)
+		return true
+	}
+
+	runJournal, err := journal.Open(appConfig.GetJournalDir(), runID)
+	if err != nil {
+		log.Error("error:", err)
+		return true
+	}
+	runConfig.SetJournal(runJournal)
+
+	log.WithFields(logrus.Fields{
+		"journal_dir": appConfig.GetJournalDir(),
+		"plans":       len(run.Plans),
+		"handled":     len(run.Done),
+	}).Infof("Resuming run %s", runID)
+
+	var problemsEncountered bool
+	var runResults paths.ProcessingResults
+
+	// Combined results of all plans for the current path, provided to the
+	// post hook once all plans for the path have been handled.
+	var pathResults paths.PathPruningResults
+
+	// Set if the pre hook vetoed handling the current path, or any path of
+	// the run.
+	var pathVetoed, runVetoed bool
+
+	for i, plan := range run.Plans {
+
+		pending := run.Pending(plan)
+
+		pathConfig := runConfig.ForPath(plan.Path)
+		planConfig := pathConfig
+
+		// Plans for the lifecycle stages of a path are recorded one after
+		// another, so the pre hook runs before the first of them.
+		if i == 0 || run.Plans[i-1].Path != plan.Path {
+			pathVetoed = false
+			if hasPending(run, plan.Path) && pathConfig.GetPreHook() != "" {
+				if err := hooks.RunPre(pathConfig, plan.Path); err != nil {
+					problemsEncountered = true
+					pathVetoed = true
+					runVetoed = true
+
+					log.WithFields(logrus.Fields{
+						"path": plan.Path,
+					}).Warnf("Skipping resume of path %q, vetoed by pre hook: %s", plan.Path, err)
+				}
+			}
+		}
+
+		if pathVetoed {
+			continue
+		}
+
+		stageName := ""
+		if plan.Stage != nil {
+			if *plan.Stage < 0 || *plan.Stage >= len(runConfig.Stages) {
+				problemsEncountered = true
+				log.Error("error:", fmt.Errorf(
+					"plan for path %q refers to unknown lifecycle stage %d", plan.Path, *plan.Stage))
+				_ = runJournal.Close()
+				return problemsEncountered
+			}
+			stage := runConfig.Stages[*plan.Stage]
+			stageName = stage.Action
+			planConfig = pathConfig.ForStage(stage, plan.Path)
+		}
+
+		log.WithFields(logrus.Fields{
+			"path":    plan.Path,
+			"stage":   stageName,
+			"planned": len(plan.Files),
+		}).Infof("%d of %d files planned for path %q not yet handled",
+			len(pending), len(plan.Files), plan.Path)
+
+		if len(pending) > 0 {
+			results, err := paths.ResumePlan(planConfig, pending)
+
+			runResults.Add(results)
+			pathResults.Add(results)
+
+			logPruningResults(appConfig.GetLogger(), results, i+1)
+
+			if err == nil && len(results.FailedRemovals) > 0 && !runConfig.GetIgnoreErrors() {
+				err = fmt.Errorf("%d files failed to be handled", len(results.FailedRemovals))
+			}
+
+			if err != nil {
+				problemsEncountered = true
+
+				log.Warnf("Error encountered while resuming path %s: %s", plan.Path, err)
+
+				if !runConfig.GetIgnoreErrors() {
+					runPostHook(pathConfig, plan.Path, pathResults, i+1)
+					log.WithFields(logrus.Fields{
+						"ignore_errors": runConfig.GetIgnoreErrors(),
+					}).Warn("Error encountered and option to ignore errors not set. Run remains resumable")
+					if err := runJournal.Close(); err != nil {
+						log.Error("error:", err)
+					}
+					return problemsEncountered
+				}
+				log.Warn("Error encountered, but continuing as requested.")
+			}
+		}
+
+		if i == len(run.Plans)-1 || run.Plans[i+1].Path != plan.Path {
+			if len(pathResults.Outcomes) > 0 && !runPostHook(pathConfig, plan.Path, pathResults, i+1) {
+				problemsEncountered = true
+			}
+			pathResults = paths.PathPruningResults{}
+		}
+	}
+
+	switch {
+	case runVetoed:
+		log.Warn("Paths vetoed by pre hook. Run remains resumable")
+		if err := runJournal.Close(); err != nil {
+			log.Error("error:", err)
+		}
+	default:
+		if err := runJournal.Complete(); err != nil {
+			problemsEncountered = true
+			log.Error("error:", err)
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"success_removed": runResults.SuccessRemoved,
+		"success_size":    units.ByteCountIEC(runResults.SuccessTotalFileSize),
+		"failed_removed":  runResults.FailedRemoved,
+		"failed_size":     units.ByteCountIEC(runResults.FailedTotalFileSize),
+		"modified":        runResults.Modified,
+		"skipped":         runResults.Skipped,
+	}).Infof("Run %s resumed", runID)
+
+	return problemsEncountered
+}
+
+// hasPending indicates whether any plan of the run for the specified path
+// has files which were not yet handled.
+func hasPending(run journal.Run, path string) bool {
+	for _, plan := range run.Plans {
+		if plan.Path == path && len(run.Pending(plan)) > 0 {
+			return true
+		}
+	}
+	return false
+}
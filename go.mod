@@ -20,11 +20,11 @@ go 1.23.0
 
 require (
 	github.com/alexflint/go-arg v1.5.1
+	github.com/klauspost/compress v1.18.0
 	github.com/pelletier/go-toml/v2 v2.2.4
 	github.com/sirupsen/logrus v1.9.3
+	golang.org/x/sys v0.33.0
+	golang.org/x/text v0.26.0
 )
 
-require (
-	github.com/alexflint/go-scalar v1.2.0 // indirect
-	golang.org/x/sys v0.33.0 // indirect
-)
+require github.com/alexflint/go-scalar v1.2.0 // indirect
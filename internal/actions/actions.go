@@ -0,0 +1,187 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package actions provides the actions applied to files selected for
+// removal. Each action handles a single file at a time and reports the
+// outcome, allowing new ways of handling files to be added without changing
+// how paths are processed.
+package actions
+
+import (
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+)
+
+// Action names reported in outcomes and logged messages.
+const (
+	NameDelete     string = "delete"
+	NameShred      string = "shred"
+	NameTruncate   string = "truncate"
+	NameMove       string = "move"
+	NameQuarantine string = "quarantine"
+	NameArchive    string = "archive"
+	NameCompress   string = "compress"
+	NameExec       string = "exec"
+)
+
+// Status indicates the result of applying an action to a single file.
+type Status int
+
+// Results of applying an action to a single file.
+const (
+	// The action was applied successfully.
+	Succeeded Status = iota
+
+	// The action could not be applied.
+	Failed
+
+	// The action was not applied as the file does not need it (e.g., the
+	// file is already compressed).
+	Skipped
+)
+
+// String returns the name of the status.
+func (s Status) String() string {
+	switch s {
+	case Succeeded:
+		return "succeeded"
+	case Failed:
+		return "failed"
+	case Skipped:
+		return "skipped"
+	default:
+		return "unknown"
+	}
+}
+
+// Outcome records the result of applying an action to a single file.
+type Outcome struct {
+
+	// Name of the action applied to the file.
+	Action string
+
+	// The file the action was applied to.
+	File matches.FileMatch
+
+	// Result of applying the action.
+	Status Status
+
+	// Error encountered while applying the action, if any.
+	Err error
+
+	// Reason the file was skipped, if it was.
+	SkipReason string
+
+	// Indicates whether the file no longer exists at its original path.
+	Removed bool
+
+	// Number of bytes saved without removing the file (e.g., by compressing
+	// or truncating it).
+	Saved int64
+
+	// Location the file (or a copy of it) was placed, if any.
+	Destination string
+
+	// Instructions for reversing the action, if it can be reversed.
+	RollbackHint string
+}
+
+// Action is implemented by each way of handling files selected for removal.
+type Action interface {
+
+	// Name returns the name of the action.
+	Name() string
+
+	// Describe returns a description of what applying the action to the
+	// file would do. This is used to report the intended result of a
+	// dry-run.
+	Describe(file matches.FileMatch) string
+
+	// Apply applies the action to the file. The returned outcome reports the
+	// result even if an error is returned.
+	Apply(file matches.FileMatch) (Outcome, error)
+
+	// RollbackHint returns instructions for reversing the action once
+	// applied to the file. An empty string is returned if the action cannot
+	// be reversed.
+	RollbackHint(file matches.FileMatch) string
+}
+
+// Preparer is implemented by actions which need to process all files of a
+// path before the action is applied to each file. Files which are not ready
+// are reported via failed outcomes and must not be passed to Apply.
+type Preparer interface {
+	Prepare(files matches.FileMatches) (ready matches.FileMatches, failed []Outcome, err error)
+}
+
+// New returns the action selected by the provided configuration. Files are
+// removed unless another action is configured. Actions which remove files
+// fall back to truncating files held open by another process if requested.
+// If configured, a command is run for each file once the action was applied,
+// and files are archived before the action is applied.
+func New(c *config.Config) Action {
+
+	var action Action
+
+	switch {
+	case c.GetTruncate():
+		action = Truncate{config: c}
+	case c.GetCompress() != "":
+		action = Compress{config: c}
+	case c.GetMoveDir() != "":
+		action = Move{config: c}
+	case c.GetQuarantineDir() != "":
+		action = Quarantine{config: c}
+	case c.GetShredPasses() > 0:
+		action = Shred{config: c}
+	default:
+		action = Delete{config: c}
+	}
+
+	switch action.(type) {
+	case Delete, Shred:
+		if c.GetTruncateOpen() {
+			action = newTruncateOpen(action, c)
+		}
+	}
+
+	if c.GetExecCommand() != "" {
+		action = newExec(action, c)
+	}
+
+	if c.GetArchiveDir() != "" {
+		action = &Archive{config: c, inner: action}
+	}
+
+	return action
+}
+
+// outcome returns an outcome for the action applied to the file.
+func outcome(action Action, file matches.FileMatch, err error) Outcome {
+
+	result := Outcome{
+		Action: action.Name(),
+		File:   file,
+		Status: Succeeded,
+		Err:    err,
+	}
+
+	if err != nil {
+		result.Status = Failed
+	}
+
+	return result
+}
@@ -0,0 +1,255 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+)
+
+func newTestFileMatch(t *testing.T, path string) matches.FileMatch {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Repeat("action test\n", 100)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return matches.FileMatch{FileInfo: info, Path: path}
+}
+
+func TestNew(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		configure func(c *config.Config)
+		want      string
+	}{
+		{
+			name:      "default",
+			configure: func(c *config.Config) {},
+			want:      NameDelete,
+		},
+		{
+			name:      "shred",
+			configure: func(c *config.Config) { *c.ShredPasses = 1 },
+			want:      NameShred,
+		},
+		{
+			name:      "truncate",
+			configure: func(c *config.Config) { *c.Truncate = true },
+			want:      NameTruncate,
+		},
+		{
+			name:      "compress",
+			configure: func(c *config.Config) { *c.Compress = config.CompressGzip },
+			want:      NameCompress,
+		},
+		{
+			name:      "quarantine",
+			configure: func(c *config.Config) { *c.QuarantineDir = "/tmp/elbow/quarantine" },
+			want:      NameQuarantine,
+		},
+		{
+			name:      "archive",
+			configure: func(c *config.Config) { *c.ArchiveDir = "/tmp/elbow/archive" },
+			want:      NameArchive,
+		},
+		{
+			name:      "exec",
+			configure: func(c *config.Config) { *c.ExecCommand = "true {{.Path}}" },
+			want:      NameExec,
+		},
+		{
+			name: "move stage",
+			configure: func(c *config.Config) {
+				*c = *c.ForStage(config.Stage{Action: config.StageActionMove, Destination: "/tmp/elbow/archive"}, "/tmp/elbow")
+			},
+			want: NameMove,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.NewDefaultConfig()
+			tt.configure(&c)
+
+			if got := New(&c).Name(); got != tt.want {
+				t.Errorf("got action %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		configure   func(c *config.Config, dir string)
+		wantRemoved bool
+		wantStatus  Status
+		wantHint    bool
+	}{
+		{
+			name:        "delete",
+			configure:   func(c *config.Config, dir string) {},
+			wantRemoved: true,
+			wantStatus:  Succeeded,
+		},
+		{
+			name: "compress",
+			configure: func(c *config.Config, dir string) {
+				*c.Compress = config.CompressGzip
+			},
+			wantRemoved: false,
+			wantStatus:  Succeeded,
+			wantHint:    true,
+		},
+		{
+			name: "quarantine",
+			configure: func(c *config.Config, dir string) {
+				*c.QuarantineDir = filepath.Join(dir, "quarantine")
+			},
+			wantRemoved: true,
+			wantStatus:  Succeeded,
+			wantHint:    true,
+		},
+		{
+			name: "move",
+			configure: func(c *config.Config, dir string) {
+				stage := config.Stage{Action: config.StageActionMove, Destination: filepath.Join(dir, "archive")}
+				*c = *c.ForStage(stage, filepath.Join(dir, "search"))
+			},
+			wantRemoved: true,
+			wantStatus:  Succeeded,
+			wantHint:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			c := config.NewDefaultConfig()
+			c.GetLogger().SetOutput(io.Discard)
+			tt.configure(&c, dir)
+
+			file := newTestFileMatch(t, filepath.Join(dir, "search", "sub", "app.log"))
+
+			action := New(&c)
+
+			if description := action.Describe(file); !strings.Contains(description, file.Path) {
+				t.Errorf("description %q does not mention file %s", description, file.Path)
+			}
+
+			outcome, err := action.Apply(file)
+			if err != nil {
+				t.Fatalf("Apply() failed: %s", err)
+			}
+
+			if outcome.Action != action.Name() {
+				t.Errorf("got outcome action %q, want %q", outcome.Action, action.Name())
+			}
+
+			if outcome.Status != tt.wantStatus {
+				t.Errorf("got status %s, want %s", outcome.Status, tt.wantStatus)
+			}
+
+			if outcome.Removed != tt.wantRemoved {
+				t.Errorf("got removed %t, want %t", outcome.Removed, tt.wantRemoved)
+			}
+
+			if _, err := os.Stat(file.Path); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("file %s still present after applying action", file.Path)
+			}
+
+			if (outcome.RollbackHint != "") != tt.wantHint {
+				t.Errorf("got rollback hint %q, want hint: %t", outcome.RollbackHint, tt.wantHint)
+			}
+
+			if outcome.Destination != "" {
+				if _, err := os.Stat(outcome.Destination); err != nil {
+					t.Errorf("destination %s missing: %s", outcome.Destination, err)
+				}
+			}
+		})
+	}
+}
+
+func TestArchivePrepare(t *testing.T) {
+
+	dir := t.TempDir()
+	searchDir := filepath.Join(dir, "search")
+
+	c := config.NewDefaultConfig()
+	*c.ArchiveDir = filepath.Join(dir, "archive")
+	c.GetLogger().SetOutput(io.Discard)
+	pathConfig := c.ForPath(searchDir)
+
+	files := matches.FileMatches{
+		newTestFileMatch(t, filepath.Join(searchDir, "a.log")),
+		newTestFileMatch(t, filepath.Join(searchDir, "b.log")),
+	}
+
+	missing := newTestFileMatch(t, filepath.Join(searchDir, "missing.log"))
+	if err := os.Remove(missing.Path); err != nil {
+		t.Fatal(err)
+	}
+	files = append(files, missing)
+
+	action := New(pathConfig)
+
+	preparer, ok := action.(Preparer)
+	if !ok {
+		t.Fatalf("archive action does not implement Preparer")
+	}
+
+	ready, failed, err := preparer.Prepare(files)
+	if err != nil {
+		t.Fatalf("Prepare() failed: %s", err)
+	}
+
+	if len(ready) != 2 || len(failed) != 1 || failed[0].File.Path != missing.Path {
+		t.Fatalf("got %d ready and %d failed files, want 2 and 1 (%s)", len(ready), len(failed), missing.Path)
+	}
+
+	for _, file := range ready {
+		outcome, err := action.Apply(file)
+		if err != nil {
+			t.Fatalf("Apply() failed: %s", err)
+		}
+
+		if !outcome.Removed || outcome.Destination == "" || outcome.RollbackHint == "" {
+			t.Errorf("unexpected outcome for archived file: %+v", outcome)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/atc0005/elbow/internal/archive"
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/sirupsen/logrus"
+)
+
+// Archive bundles files into a verified archive before applying the wrapped
+// action (typically removal) to each file. Files which could not be
+// archived are not passed to the wrapped action.
+type Archive struct {
+	config      *config.Config
+	inner       Action
+	archivePath string
+}
+
+// Name returns the name of the action.
+func (a *Archive) Name() string {
+	return NameArchive
+}
+
+// Describe returns a description of archiving the file and applying the
+// wrapped action.
+func (a *Archive) Describe(file matches.FileMatch) string {
+	return fmt.Sprintf("add %s to an archive in %s, then %s",
+		matches.DisplayName(file.Path),
+		a.config.GetArchiveDir(),
+		a.inner.Describe(file),
+	)
+}
+
+// Prepare archives all files, returning the files which were archived and
+// verified.
+func (a *Archive) Prepare(files matches.FileMatches) (matches.FileMatches, []Outcome, error) {
+
+	log := a.config.GetLogger()
+
+	log.WithFields(logrus.Fields{
+		"archive_dir":    a.config.GetArchiveDir(),
+		"archive_format": a.config.GetArchiveFormat(),
+		"archive_level":  a.config.GetArchiveLevel(),
+	}).Debug("Archiving files before removal")
+
+	results, err := archive.Create(a.config, a.config.GetSearchRoot(), files)
+
+	a.archivePath = results.ArchivePath
+
+	log.WithFields(logrus.Fields{
+		"archive": results.ArchivePath,
+	}).Infof("%d files archived (%s)",
+		len(results.Archived),
+		results.Archived.TotalFileSizeHR())
+
+	failed := make([]Outcome, 0, len(results.Failed))
+	for _, file := range results.Failed {
+		failedErr := err
+		if failedErr == nil {
+			failedErr = fmt.Errorf("unable to archive %s", file.Path)
+		}
+		failed = append(failed, outcome(a, file, failedErr))
+	}
+
+	// Archived files are prepared by the wrapped action as well, if needed.
+	if preparer, ok := a.inner.(Preparer); ok && len(results.Archived) > 0 {
+		ready, innerFailed, innerErr := preparer.Prepare(results.Archived)
+		if err == nil {
+			err = innerErr
+		}
+		return ready, append(failed, innerFailed...), err
+	}
+
+	return results.Archived, failed, err
+}
+
+// Apply applies the wrapped action to an archived file.
+func (a *Archive) Apply(file matches.FileMatch) (Outcome, error) {
+
+	result, err := a.inner.Apply(file)
+	result.Action = a.Name()
+	result.Destination = a.archivePath
+	result.RollbackHint = a.RollbackHint(file)
+
+	return result, err
+}
+
+// RollbackHint returns a command which extracts the file from the archive.
+func (a *Archive) RollbackHint(file matches.FileMatch) string {
+
+	if a.archivePath == "" {
+		return ""
+	}
+
+	entry, err := filepath.Rel(a.config.GetSearchRoot(), file.Path)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("tar -xaf %q -C %q %q",
+		a.archivePath, a.config.GetSearchRoot(), filepath.ToSlash(entry))
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/elbow/internal/compress"
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/unlink"
+	"github.com/sirupsen/logrus"
+)
+
+// Compress compresses files in place instead of removing them.
+type Compress struct {
+	config *config.Config
+}
+
+// Name returns the name of the action.
+func (c Compress) Name() string {
+	return NameCompress
+}
+
+// Describe returns a description of compressing the file.
+func (c Compress) Describe(file matches.FileMatch) string {
+	return fmt.Sprintf("compress %s to %s%s using %s",
+		matches.DisplayName(file.Path),
+		matches.DisplayName(file.Path),
+		compress.Extension(c.config.GetCompress()),
+		c.config.GetCompress(),
+	)
+}
+
+// Apply compresses the file. Files which are already compressed are
+// skipped.
+func (c Compress) Apply(file matches.FileMatch) (Outcome, error) {
+
+	log := c.config.GetLogger()
+
+	log.WithFields(logrus.Fields{
+		"removal_enabled": c.config.GetRemove(),
+		"compress":        c.config.GetCompress(),
+
+		// fully-qualified path to the file
+		"file": matches.DisplayName(file.Path),
+	}).Debug("Compressing file")
+
+	compressed, err := compress.File(file.Path, identity(file), c.config)
+	if errors.Is(err, unlink.ErrChanged) {
+		return skipChanged(c, c.config, file, err), nil
+	}
+
+	if errors.Is(err, compress.ErrAlreadyCompressed) {
+		log.WithFields(logrus.Fields{
+			"file": matches.DisplayName(file.Path),
+		}).Info("File already compressed, skipping")
+
+		result := outcome(c, file, nil)
+		result.Status = Skipped
+		result.SkipReason = "already compressed"
+
+		return result, nil
+	}
+
+	result := outcome(c, file, err)
+	if err == nil {
+		log.WithFields(logrus.Fields{
+			"file":            matches.DisplayName(file.Path),
+			"compressed_file": matches.DisplayName(compressed.CompressedPath),
+			"original_size":   compressed.OriginalSize,
+			"compressed_size": compressed.CompressedSize,
+		}).Debug("File compressed")
+
+		result.Saved = compressed.Saved()
+		result.Destination = compressed.CompressedPath
+		result.RollbackHint = c.RollbackHint(file)
+	}
+
+	return result, err
+}
+
+// RollbackHint returns a command which decompresses the file.
+func (c Compress) RollbackHint(file matches.FileMatch) string {
+
+	compressedPath := file.Path + compress.Extension(c.config.GetCompress())
+
+	switch c.config.GetCompress() {
+	case config.CompressZstd:
+		return fmt.Sprintf("zstd -d --rm %q", compressedPath)
+	default:
+		return fmt.Sprintf("gzip -d %q", compressedPath)
+	}
+}
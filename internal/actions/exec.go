@@ -0,0 +1,259 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/sirupsen/logrus"
+)
+
+// maxExecOutput is the maximum number of bytes of command output included
+// in error messages.
+const maxExecOutput = 512
+
+// execWaitDelay is the time allowed for the output of a command to be
+// collected once the command exited or timed out. Processes started by the
+// command may keep its output open indefinitely.
+const execWaitDelay = 2 * time.Second
+
+// ExecData is the data available to exec command templates.
+type ExecData struct {
+	Path    string
+	Name    string
+	Dir     string
+	Size    int64
+	ModTime string
+	RunID   string
+}
+
+// Exec runs a command for each file once the wrapped action (typically
+// removal) has been applied to it successfully, e.g., to deregister the file
+// from a catalog. Files for which the command fails are reported as failed.
+type Exec struct {
+	config *config.Config
+	inner  Action
+
+	// Limits the number of commands run at the same time.
+	sem chan struct{}
+}
+
+// newExec returns an Exec action running the configured command once the
+// wrapped action has been applied.
+func newExec(inner Action, c *config.Config) *Exec {
+
+	concurrency := c.GetExecConcurrency()
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Generate the run ID before commands are run concurrently.
+	c.GetRunID()
+
+	return &Exec{
+		config: c,
+		inner:  inner,
+		sem:    make(chan struct{}, concurrency),
+	}
+}
+
+// Name returns the name of the action.
+func (e *Exec) Name() string {
+	return NameExec
+}
+
+// Describe returns a description of applying the wrapped action and running
+// the command.
+func (e *Exec) Describe(file matches.FileMatch) string {
+
+	argv, err := ExecArgs(e.config.GetExecCommand(), newExecData(file, e.config))
+	if err != nil {
+		return fmt.Sprintf("%s, then run %q (invalid template: %s)",
+			e.inner.Describe(file), e.config.GetExecCommand(), err)
+	}
+
+	return fmt.Sprintf("%s, then run %q", e.inner.Describe(file), strings.Join(argv, " "))
+}
+
+// Apply applies the wrapped action to the file and runs the command once
+// the action succeeded. The file is reported as failed if the command exits
+// with a non-zero status, even though the wrapped action was applied.
+func (e *Exec) Apply(file matches.FileMatch) (Outcome, error) {
+
+	result, err := e.inner.Apply(file)
+	result.Action = e.Name()
+
+	if err != nil || result.Status != Succeeded {
+		return result, err
+	}
+
+	e.sem <- struct{}{}
+	err = e.run(file)
+	<-e.sem
+
+	if err != nil {
+		err = fmt.Errorf("%s applied, but %w", e.inner.Name(), err)
+		result.Status = Failed
+		result.Err = err
+		return result, err
+	}
+
+	return result, nil
+}
+
+// RollbackHint returns the rollback hint of the wrapped action. The effects
+// of the command itself cannot be reversed by elbow.
+func (e *Exec) RollbackHint(file matches.FileMatch) string {
+	return e.inner.RollbackHint(file)
+}
+
+// run runs the command for a single file. An error is returned if the
+// command cannot be started, exits with a non-zero status or exceeds the
+// configured timeout.
+func (e *Exec) run(file matches.FileMatch) error {
+
+	log := e.config.GetLogger()
+
+	data := newExecData(file, e.config)
+
+	argv, err := ExecArgs(e.config.GetExecCommand(), data)
+	if err != nil {
+		return err
+	}
+
+	if len(argv) == 0 {
+		return fmt.Errorf("empty exec command")
+	}
+
+	ctx := context.Background()
+	if timeout := e.config.GetExecTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	// The command is provided by the user running this application.
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(),
+		"ELBOW_FILE_PATH="+data.Path,
+		"ELBOW_FILE_NAME="+data.Name,
+		"ELBOW_FILE_DIR="+data.Dir,
+		"ELBOW_FILE_SIZE="+strconv.FormatInt(data.Size, 10),
+		"ELBOW_FILE_MTIME="+data.ModTime,
+		"ELBOW_RUN_ID="+data.RunID,
+	)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.WaitDelay = execWaitDelay
+
+	log.WithFields(logrus.Fields{
+		"command": argv,
+		"file":    matches.DisplayName(file.Path),
+	}).Debug("Running exec command")
+
+	err = cmd.Run()
+
+	log.WithFields(logrus.Fields{
+		"command": argv,
+		"file":    matches.DisplayName(file.Path),
+		"output":  strings.TrimSpace(output.String()),
+	}).Debug("Exec command completed")
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("command %q timed out after %ds", argv[0], e.config.GetExecTimeout())
+	case errors.Is(err, exec.ErrWaitDelay):
+		log.WithFields(logrus.Fields{
+			"command": argv,
+			"file":    matches.DisplayName(file.Path),
+		}).Warn("Command exited, but left its output open")
+	case err != nil && strings.TrimSpace(output.String()) == "":
+		return fmt.Errorf("command %q failed: %w", argv[0], err)
+	case err != nil:
+		return fmt.Errorf("command %q failed: %w: %s", argv[0], err, truncateOutput(output.String()))
+	}
+
+	return nil
+}
+
+// ExecArgs splits the command on whitespace and expands each argument as a
+// template using the provided data.
+func ExecArgs(command string, data ExecData) ([]string, error) {
+
+	fields := strings.Fields(command)
+	argv := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		tmpl, err := template.New("exec").Option("missingkey=error").Parse(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exec command argument %q: %w", field, err)
+		}
+
+		var arg strings.Builder
+		if err := tmpl.Execute(&arg, data); err != nil {
+			return nil, fmt.Errorf("invalid exec command argument %q: %w", field, err)
+		}
+
+		argv = append(argv, arg.String())
+	}
+
+	return argv, nil
+}
+
+// newExecData returns the template data for the file.
+func newExecData(file matches.FileMatch, c *config.Config) ExecData {
+
+	data := ExecData{
+		Path:  file.Path,
+		Name:  filepath.Base(file.Path),
+		Dir:   filepath.Dir(file.Path),
+		RunID: c.GetRunID(),
+	}
+
+	if file.FileInfo != nil {
+		data.Size = file.Size()
+		data.ModTime = file.ModTime().Format(time.RFC3339)
+	}
+
+	return data
+}
+
+// truncateOutput limits command output included in error messages.
+func truncateOutput(output string) string {
+
+	output = strings.TrimSpace(output)
+	if len(output) > maxExecOutput {
+		output = output[:maxExecOutput] + "..."
+	}
+
+	return output
+}
@@ -0,0 +1,204 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+)
+
+func TestExecArgs(t *testing.T) {
+
+	data := ExecData{
+		Path:    "/var/log/app/app.log",
+		Name:    "app.log",
+		Dir:     "/var/log/app",
+		Size:    1024,
+		ModTime: "2020-01-02T03:04:05Z",
+		RunID:   "run-1",
+	}
+
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{
+			command: "catalog deregister {{.Path}}",
+			want:    []string{"catalog", "deregister", "/var/log/app/app.log"},
+		},
+		{
+			command: "catalog --size={{.Size}} --mtime={{.ModTime}} --run={{.RunID}} {{.Dir}}/{{.Name}}",
+			want:    []string{"catalog", "--size=1024", "--mtime=2020-01-02T03:04:05Z", "--run=run-1", "/var/log/app/app.log"},
+		},
+		{
+			command: "catalog {{.Owner}}",
+			wantErr: true,
+		},
+		{
+			command: "catalog {{.Path}",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got, err := ExecArgs(tt.command, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %t", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecApply(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("exec tests rely on a POSIX shell")
+	}
+
+	dir := t.TempDir()
+
+	c := config.NewDefaultConfig()
+	c.GetLogger().SetOutput(io.Discard)
+	*c.ExecConcurrency = 2
+
+	// The script fails for files named "b.log" and records the environment
+	// provided for all others.
+	*c.ExecCommand = "sh " + filepath.Join(dir, "hook.sh")
+	script := "test \"$ELBOW_FILE_NAME\" != b.log && echo \"$ELBOW_FILE_SIZE $ELBOW_RUN_ID\" > \"$ELBOW_FILE_PATH.exec\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "hook.sh"), []byte(script), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	files := matches.FileMatches{
+		newTestFileMatch(t, filepath.Join(dir, "a.log")),
+		newTestFileMatch(t, filepath.Join(dir, "b.log")),
+		newTestFileMatch(t, filepath.Join(dir, "c.log")),
+	}
+
+	action := New(&c)
+
+	for _, file := range files {
+		outcome, err := action.Apply(file)
+
+		// The command only runs once the file has been removed.
+		if _, statErr := os.Stat(file.Path); !errors.Is(statErr, os.ErrNotExist) {
+			t.Errorf("file %s still present after applying action", file.Path)
+		}
+
+		if filepath.Base(file.Path) == "b.log" {
+			if err == nil || outcome.Status != Failed || outcome.Err == nil || !outcome.Removed {
+				t.Errorf("unexpected outcome for failed command: %+v", outcome)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Apply() failed: %s", err)
+		}
+
+		if outcome.Action != NameExec || outcome.Status != Succeeded || !outcome.Removed {
+			t.Errorf("unexpected outcome for file: %+v", outcome)
+		}
+
+		if _, err := os.Stat(file.Path + ".exec"); err != nil {
+			t.Errorf("command did not run for %s: %s", file.Path, err)
+		}
+	}
+
+	// The command is not run if the wrapped action fails.
+	missing := newTestFileMatch(t, filepath.Join(dir, "d.log"))
+	if err := os.Remove(missing.Path); err != nil {
+		t.Fatal(err)
+	}
+
+	outcome, err := action.Apply(missing)
+	if err == nil || outcome.Status != Failed {
+		t.Errorf("unexpected outcome for missing file: %+v", outcome)
+	}
+
+	if _, err := os.Stat(missing.Path + ".exec"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("command ran for file which could not be removed")
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("exec tests rely on a POSIX shell")
+	}
+
+	c := config.NewDefaultConfig()
+	c.GetLogger().SetOutput(io.Discard)
+	*c.ExecCommand = "sleep 5"
+	*c.ExecTimeout = 1
+
+	file := newTestFileMatch(t, filepath.Join(t.TempDir(), "a.log"))
+
+	outcome, err := New(&c).Apply(file)
+	if err == nil || outcome.Status != Failed {
+		t.Fatalf("got outcome %+v and error %v, want failed outcome", outcome, err)
+	}
+}
+
+func TestExecTimeoutBackgroundChild(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("exec tests rely on a POSIX shell")
+	}
+
+	// The backgrounded sleep inherits the output of the command and
+	// outlives it when the command is killed on timeout.
+	dir := t.TempDir()
+	script := filepath.Join(dir, "daemon.sh")
+	if err := os.WriteFile(script, []byte("sleep 30 &\nsleep 30\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := config.NewDefaultConfig()
+	c.GetLogger().SetOutput(io.Discard)
+	*c.ExecCommand = "sh " + script
+	*c.ExecTimeout = 1
+
+	file := newTestFileMatch(t, filepath.Join(dir, "a.log"))
+
+	start := time.Now()
+	outcome, err := New(&c).Apply(file)
+	elapsed := time.Since(start)
+
+	if err == nil || outcome.Status != Failed {
+		t.Fatalf("got outcome %+v and error %v, want failed outcome", outcome, err)
+	}
+
+	if elapsed > 10*time.Second {
+		t.Errorf("command returned after %s; waited on background child", elapsed)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/quarantine"
+	"github.com/atc0005/elbow/internal/unlink"
+	"github.com/sirupsen/logrus"
+)
+
+// Move moves files into a destination directory, keeping their location
+// relative to the search path they were found in.
+type Move struct {
+	config *config.Config
+}
+
+// Name returns the name of the action.
+func (m Move) Name() string {
+	return NameMove
+}
+
+// Describe returns a description of moving the file.
+func (m Move) Describe(file matches.FileMatch) string {
+	return fmt.Sprintf("move %s to %s",
+		matches.DisplayName(file.Path), matches.DisplayName(m.destination(file.Path)))
+}
+
+// Apply moves the file.
+func (m Move) Apply(file matches.FileMatch) (Outcome, error) {
+
+	destination := m.destination(file.Path)
+
+	m.config.GetLogger().WithFields(logrus.Fields{
+		"removal_enabled": m.config.GetRemove(),
+		"move_dir":        m.config.GetMoveDir(),
+		"destination":     matches.DisplayName(destination),
+
+		// fully-qualified path to the file
+		"file": matches.DisplayName(file.Path),
+	}).Debug("Moving file")
+
+	err := os.MkdirAll(filepath.Dir(destination), 0750)
+	if err == nil {
+		err = quarantine.Move(m.config.GetSearchRoot(), file.Path, destination, identity(file))
+	}
+	if errors.Is(err, unlink.ErrChanged) {
+		return skipChanged(m, m.config, file, err), nil
+	}
+
+	result := outcome(m, file, err)
+	result.Removed = err == nil
+	if err == nil {
+		result.Destination = destination
+		result.RollbackHint = m.RollbackHint(file)
+	}
+
+	return result, err
+}
+
+// RollbackHint returns a command which moves the file back to its original
+// location.
+func (m Move) RollbackHint(file matches.FileMatch) string {
+	return fmt.Sprintf("mv %q %q", m.destination(file.Path), file.Path)
+}
+
+// destination returns the path the file is moved to. Files found outside of
+// the search path are placed directly within the destination directory.
+func (m Move) destination(path string) string {
+
+	relPath, err := filepath.Rel(m.config.GetSearchRoot(), path)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		relPath = filepath.Base(path)
+	}
+
+	return filepath.Join(m.config.GetMoveDir(), relPath)
+}
+
+// Quarantine moves files into the quarantine directory so that they can be
+// restored later.
+type Quarantine struct {
+	config *config.Config
+}
+
+// Name returns the name of the action.
+func (q Quarantine) Name() string {
+	return NameQuarantine
+}
+
+// Describe returns a description of quarantining the file.
+func (q Quarantine) Describe(file matches.FileMatch) string {
+	return fmt.Sprintf("move %s into quarantine directory %s",
+		matches.DisplayName(file.Path), q.config.GetQuarantineDir())
+}
+
+// Apply quarantines the file.
+func (q Quarantine) Apply(file matches.FileMatch) (Outcome, error) {
+
+	q.config.GetLogger().WithFields(logrus.Fields{
+		"removal_enabled": q.config.GetRemove(),
+		"quarantine_dir":  q.config.GetQuarantineDir(),
+		"run_id":          q.config.GetRunID(),
+
+		// fully-qualified path to the file
+		"file": matches.DisplayName(file.Path),
+	}).Debug("Quarantining file")
+
+	entry, err := quarantine.Quarantine(file, q.config)
+	if errors.Is(err, unlink.ErrChanged) {
+		return skipChanged(q, q.config, file, err), nil
+	}
+
+	result := outcome(q, file, err)
+	result.Removed = err == nil
+	if err == nil {
+		result.Destination = entry.QuarantinePath
+		result.RollbackHint = q.RollbackHint(file)
+	}
+
+	return result, err
+}
+
+// RollbackHint returns the restore subcommand which restores the file.
+func (q Quarantine) RollbackHint(file matches.FileMatch) string {
+	return fmt.Sprintf("elbow restore --quarantine-dir %q --run %s %q",
+		q.config.GetQuarantineDir(), q.config.GetRunID(), file.Path)
+}
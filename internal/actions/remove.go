@@ -0,0 +1,288 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/shred"
+	"github.com/atc0005/elbow/internal/truncate"
+	"github.com/atc0005/elbow/internal/unlink"
+	"github.com/sirupsen/logrus"
+)
+
+// Delete removes files.
+type Delete struct {
+	config *config.Config
+}
+
+// Name returns the name of the action.
+func (d Delete) Name() string {
+	return NameDelete
+}
+
+// Describe returns a description of removing the file.
+func (d Delete) Describe(file matches.FileMatch) string {
+	return fmt.Sprintf("remove %s", matches.DisplayName(file.Path))
+}
+
+// Apply removes the file.
+func (d Delete) Apply(file matches.FileMatch) (Outcome, error) {
+
+	d.config.GetLogger().WithFields(logrus.Fields{
+		"removal_enabled": d.config.GetRemove(),
+
+		// fully-qualified path to the file
+		"file": matches.DisplayName(file.Path),
+	}).Debug("Removing file")
+
+	// We need to reference the full path here, not the short name since the
+	// current working directory may not be the same directory where the
+	// file is located
+	err := unlink.File(d.config.GetSearchRoot(), file.Path, identity(file))
+	if errors.Is(err, unlink.ErrChanged) {
+		return skipChanged(d, d.config, file, err), nil
+	}
+
+	result := outcome(d, file, err)
+	result.Removed = err == nil
+
+	return result, err
+}
+
+// RollbackHint returns an empty string as removed files can only be
+// recovered from backups.
+func (d Delete) RollbackHint(_ matches.FileMatch) string {
+	return ""
+}
+
+// identity returns the identity recorded for the file when it was found. If
+// no identity was recorded, it is derived from the file metadata instead.
+func identity(file matches.FileMatch) matches.Identity {
+	if file.Identity.IsZero() && file.FileInfo != nil {
+		return matches.NewIdentity(file.FileInfo)
+	}
+	return file.Identity
+}
+
+// skipChanged returns a skipped outcome for a file which changed since it
+// was found and was therefore left in place.
+func skipChanged(action Action, c *config.Config, file matches.FileMatch, err error) Outcome {
+
+	c.GetLogger().WithFields(logrus.Fields{
+		"file":  matches.DisplayName(file.Path),
+		"error": err,
+	}).Warn("File changed since it was found, skipping")
+
+	result := outcome(action, file, nil)
+	result.Status = Skipped
+	result.SkipReason = err.Error()
+
+	return result
+}
+
+// Shred overwrites the content of files before removing them.
+type Shred struct {
+	config *config.Config
+}
+
+// Name returns the name of the action.
+func (s Shred) Name() string {
+	return NameShred
+}
+
+// Describe returns a description of shredding the file.
+func (s Shred) Describe(file matches.FileMatch) string {
+	return fmt.Sprintf(
+		"overwrite %s %d times, then truncate, rename and remove it",
+		matches.DisplayName(file.Path),
+		s.config.GetShredPasses(),
+	)
+}
+
+// Apply shreds the file.
+func (s Shred) Apply(file matches.FileMatch) (Outcome, error) {
+
+	log := s.config.GetLogger()
+
+	log.WithFields(logrus.Fields{
+		"removal_enabled": s.config.GetRemove(),
+		"shred_passes":    s.config.GetShredPasses(),
+
+		// fully-qualified path to the file
+		"file": matches.DisplayName(file.Path),
+	}).Debug("Shredding file")
+
+	err := shred.File(s.config.GetSearchRoot(), file.Path, identity(file), s.config.GetShredPasses())
+	if errors.Is(err, unlink.ErrChanged) {
+		return skipChanged(s, s.config, file, err), nil
+	}
+
+	if err == nil {
+		log.WithFields(logrus.Fields{
+			"shredded":     true,
+			"shred_passes": s.config.GetShredPasses(),
+			"file":         matches.DisplayName(file.Path),
+		}).Info("File shredded")
+	}
+
+	result := outcome(s, file, err)
+	result.Removed = err == nil
+
+	return result, err
+}
+
+// RollbackHint returns an empty string as shredded files cannot be
+// recovered.
+func (s Shred) RollbackHint(_ matches.FileMatch) string {
+	return ""
+}
+
+// Truncate truncates files instead of removing them, optionally keeping the
+// end of each file.
+type Truncate struct {
+	config *config.Config
+}
+
+// Name returns the name of the action.
+func (t Truncate) Name() string {
+	return NameTruncate
+}
+
+// Describe returns a description of truncating the file.
+func (t Truncate) Describe(file matches.FileMatch) string {
+	switch {
+	case t.config.GetTruncateKeepLines() > 0:
+		return fmt.Sprintf("truncate %s, keeping the last %d lines",
+			matches.DisplayName(file.Path), t.config.GetTruncateKeepLines())
+	case t.config.GetTruncateKeepBytes() > 0:
+		return fmt.Sprintf("truncate %s, keeping the last %d bytes",
+			matches.DisplayName(file.Path), t.config.GetTruncateKeepBytes())
+	default:
+		return fmt.Sprintf("truncate %s", matches.DisplayName(file.Path))
+	}
+}
+
+// Apply truncates the file.
+func (t Truncate) Apply(file matches.FileMatch) (Outcome, error) {
+
+	log := t.config.GetLogger()
+
+	log.WithFields(logrus.Fields{
+		"removal_enabled": t.config.GetRemove(),
+		"keep_bytes":      t.config.GetTruncateKeepBytes(),
+		"keep_lines":      t.config.GetTruncateKeepLines(),
+
+		// fully-qualified path to the file
+		"file": matches.DisplayName(file.Path),
+	}).Debug("Truncating file")
+
+	reclaimed, err := truncate.File(
+		t.config.GetSearchRoot(),
+		file.Path,
+		identity(file),
+		int64(t.config.GetTruncateKeepBytes()),
+		t.config.GetTruncateKeepLines(),
+	)
+	if errors.Is(err, unlink.ErrChanged) {
+		return skipChanged(t, t.config, file, err), nil
+	}
+
+	result := outcome(t, file, err)
+	result.Saved = reclaimed
+
+	if err == nil {
+		log.WithFields(logrus.Fields{
+			"file":           matches.DisplayName(file.Path),
+			"reclaimed_size": reclaimed,
+		}).Debug("File truncated")
+	}
+
+	return result, err
+}
+
+// RollbackHint returns an empty string as truncated content cannot be
+// recovered.
+func (t Truncate) RollbackHint(_ matches.FileMatch) string {
+	return ""
+}
+
+// truncateOpen applies the wrapped action to files unless they are held
+// open by another process, in which case they are truncated instead as
+// removing them would not reclaim their space.
+type truncateOpen struct {
+	inner     Action
+	truncate  Truncate
+	openFiles *truncate.OpenFiles
+}
+
+// newTruncateOpen wraps the action, collecting the files currently held
+// open by other processes.
+func newTruncateOpen(inner Action, c *config.Config) Action {
+
+	openFiles, err := truncate.LoadOpenFiles()
+	if err != nil {
+		c.GetLogger().WithFields(logrus.Fields{
+			"truncate_open": c.GetTruncateOpen(),
+		}).Warnf("Unable to detect open files, files will not be truncated: %s", err)
+
+		return inner
+	}
+
+	return truncateOpen{
+		inner:     inner,
+		truncate:  Truncate{config: c},
+		openFiles: openFiles,
+	}
+}
+
+// Name returns the name of the wrapped action.
+func (to truncateOpen) Name() string {
+	return to.inner.Name()
+}
+
+// Describe returns a description of truncating the file if it is held open,
+// or of applying the wrapped action otherwise.
+func (to truncateOpen) Describe(file matches.FileMatch) string {
+	if to.openFiles.IsOpen(file.FileInfo) {
+		return to.truncate.Describe(file) + " (held open by another process)"
+	}
+	return to.inner.Describe(file)
+}
+
+// Apply truncates the file if it is held open, or applies the wrapped action
+// otherwise.
+func (to truncateOpen) Apply(file matches.FileMatch) (Outcome, error) {
+	if to.openFiles.IsOpen(file.FileInfo) {
+		// The file is likely still being written, so only confirm that it
+		// was not replaced since it was found.
+		file.Identity = identity(file).SameFile()
+		return to.truncate.Apply(file)
+	}
+	return to.inner.Apply(file)
+}
+
+// RollbackHint returns the rollback hint of the action applied to the file.
+func (to truncateOpen) RollbackHint(file matches.FileMatch) string {
+	if to.openFiles.IsOpen(file.FileInfo) {
+		return to.truncate.RollbackHint(file)
+	}
+	return to.inner.RollbackHint(file)
+}
@@ -0,0 +1,435 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package archive provides types and functions used to bundle files into a
+// compressed archive before they are removed.
+//
+// One archive is created per path and run. Each archive ends with a checksum
+// manifest listing the SHA-256 checksum of every archived file. The archive
+// is re-read and checked against these checksums before any file is
+// considered safe to remove.
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/klauspost/compress/zstd"
+	"github.com/sirupsen/logrus"
+)
+
+// ManifestName is the name of the checksum manifest stored as the final
+// entry of each archive. The manifest uses the same format as the sha256sum
+// utility.
+const ManifestName string = "SHA256SUMS"
+
+// ErrVerificationFailed indicates that an archive did not match the content
+// written to it.
+var ErrVerificationFailed = errors.New("archive verification failed")
+
+// Results represents the outcome of archiving the files for a path.
+type Results struct {
+
+	// Path to the created archive. Empty if no archive was created.
+	ArchivePath string
+
+	// Files that were archived and verified. Only these files are safe to
+	// remove.
+	Archived matches.FileMatches
+
+	// Files that could not be archived. These files must not be removed.
+	Failed matches.FileMatches
+}
+
+// Name returns the filename of the archive created for the specified path by
+// the current run. Archives created when resuming an interrupted run also
+// carry the ID of the resuming run, as the interrupted run may already have
+// created its archive.
+func Name(config *config.Config, path string) string {
+
+	name := "root"
+
+	absPath, err := filepath.Abs(path)
+	if err == nil {
+		absPath = strings.TrimPrefix(absPath, filepath.VolumeName(absPath))
+		absPath = strings.Trim(filepath.ToSlash(absPath), "/")
+		if absPath != "" {
+			name = strings.ReplaceAll(absPath, "/", "_")
+		}
+	}
+
+	if resumeRunID := config.GetResumeRunID(); resumeRunID != "" {
+		return fmt.Sprintf("%s_%s_%s.%s", name, config.GetRunID(), resumeRunID, config.GetArchiveFormat())
+	}
+
+	return fmt.Sprintf("%s_%s.%s", name, config.GetRunID(), config.GetArchiveFormat())
+}
+
+// Create bundles the specified files into a new archive in the configured
+// archive directory. Entries are named relative to the provided path. Once
+// written the archive is re-read and verified. Files are only reported as
+// archived once the archive passes verification; if verification fails all
+// files are reported as failed and an error is returned.
+func Create(config *config.Config, path string, files matches.FileMatches) (Results, error) {
+
+	log := config.GetLogger()
+
+	var results Results
+
+	if err := os.MkdirAll(config.GetArchiveDir(), 0750); err != nil {
+		results.Failed = files
+		return results, fmt.Errorf("unable to create archive directory: %w", err)
+	}
+
+	archivePath := filepath.Join(config.GetArchiveDir(), Name(config, path))
+
+	fh, err := os.OpenFile(filepath.Clean(archivePath), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		results.Failed = files
+		return results, fmt.Errorf("unable to create archive: %w", err)
+	}
+
+	results.ArchivePath = archivePath
+
+	checksums, archived, failed, err := write(config, fh, path, files)
+	results.Failed = failed
+	if err != nil {
+		_ = fh.Close()
+		_ = os.Remove(archivePath)
+		results.ArchivePath = ""
+		results.Failed = files
+		return results, err
+	}
+
+	if err := fh.Sync(); err != nil {
+		_ = fh.Close()
+		results.Failed = files
+		return results, fmt.Errorf("unable to sync archive: %w", err)
+	}
+
+	if err := fh.Close(); err != nil {
+		results.Failed = files
+		return results, fmt.Errorf("unable to close archive: %w", err)
+	}
+
+	archiveSum, err := Verify(archivePath, config.GetArchiveFormat(), checksums)
+	if err != nil {
+		results.Failed = files
+		return results, err
+	}
+
+	if err := writeChecksumFile(archivePath, archiveSum); err != nil {
+		log.WithFields(logrus.Fields{
+			"archive": archivePath,
+		}).Warnf("Unable to record archive checksum: %s", err)
+	}
+
+	results.Archived = archived
+
+	return results, nil
+}
+
+// write streams the specified files into the archive, followed by the
+// checksum manifest. Files that cannot be opened or are not regular files
+// are skipped and returned separately. The checksum of each archived file is
+// returned indexed by entry name. An error is returned if the archive itself
+// could not be written.
+func write(
+	config *config.Config,
+	w io.Writer,
+	path string,
+	files matches.FileMatches,
+) (map[string]string, matches.FileMatches, matches.FileMatches, error) {
+
+	log := config.GetLogger()
+
+	compressor, err := newCompressor(w, config.GetArchiveFormat(), config.GetArchiveLevel())
+	if err != nil {
+		return nil, nil, files, err
+	}
+
+	tw := tar.NewWriter(compressor)
+
+	checksums := make(map[string]string, len(files))
+	var names []string
+	var archived, failed matches.FileMatches
+
+	for _, file := range files {
+
+		name, err := entryName(path, file.Path)
+		if err != nil {
+			return nil, nil, files, err
+		}
+
+		sum, err := addFile(tw, name, file.Path)
+		switch {
+		case errors.Is(err, errSkipped):
+			log.WithFields(logrus.Fields{
+				"file": matches.DisplayName(file.Path),
+			}).Errorf("Error encountered while archiving file: %s", err)
+			failed = append(failed, file)
+			continue
+		case err != nil:
+			return nil, nil, files, fmt.Errorf("unable to archive %s: %w", file.Path, err)
+		}
+
+		checksums[name] = sum
+		names = append(names, name)
+		archived = append(archived, file)
+	}
+
+	var manifest strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&manifest, "%s  %s\n", checksums[name], name)
+	}
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:     ManifestName,
+		Mode:     0644,
+		Size:     int64(manifest.Len()),
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return nil, nil, files, err
+	}
+
+	if _, err := io.WriteString(tw, manifest.String()); err != nil {
+		return nil, nil, files, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, nil, files, err
+	}
+
+	if err := compressor.Close(); err != nil {
+		return nil, nil, files, err
+	}
+
+	return checksums, archived, failed, nil
+}
+
+// errSkipped indicates that a file was not added to the archive, but the
+// archive remains usable.
+var errSkipped = errors.New("file not archived")
+
+// addFile writes a single file to the archive using the specified entry name
+// and returns the SHA-256 checksum of the archived content.
+func addFile(tw *tar.Writer, name string, path string) (string, error) {
+
+	fh, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errSkipped, err)
+	}
+	defer fh.Close()
+
+	info, err := fh.Stat()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errSkipped, err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%w: not a regular file", errSkipped)
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errSkipped, err)
+	}
+	header.Name = name
+
+	if err := tw.WriteHeader(header); err != nil {
+		return "", err
+	}
+
+	// Copy exactly the number of bytes recorded in the header; a file that
+	// shrinks while being read leaves the archive unusable.
+	hash := sha256.New()
+	if _, err := io.CopyN(io.MultiWriter(tw, hash), fh, header.Size); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// entryName returns the name used for a file within the archive. Names are
+// relative to the path being processed and always use forward slashes.
+func entryName(path string, file string) (string, error) {
+
+	name, err := filepath.Rel(path, file)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine archive entry name for %s: %w", file, err)
+	}
+
+	return filepath.ToSlash(name), nil
+}
+
+// Verify re-reads the specified archive and confirms that it holds each
+// expected entry with the expected checksum and that the checksum manifest
+// stored in the archive agrees. The SHA-256 checksum of the archive file
+// itself is returned.
+func Verify(archivePath string, format string, expected map[string]string) (string, error) {
+
+	fh, err := os.Open(filepath.Clean(archivePath))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+	}
+	defer fh.Close()
+
+	archiveHash := sha256.New()
+
+	decompressor, err := newDecompressor(io.TeeReader(fh, archiveHash), format)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+	}
+	defer decompressor.Close()
+
+	tr := tar.NewReader(decompressor)
+
+	seen := make(map[string]string, len(expected))
+	var manifest map[string]string
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+		}
+
+		if header.Name == ManifestName {
+			manifest, err = parseManifest(tr)
+			if err != nil {
+				return "", fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+			}
+			continue
+		}
+
+		hash := sha256.New()
+		// #nosec G110
+		// Entries are read back from an archive we just created.
+		if _, err := io.Copy(hash, tr); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+		}
+		seen[header.Name] = hex.EncodeToString(hash.Sum(nil))
+	}
+
+	// Consume any trailing data so that the archive checksum covers the
+	// entire file.
+	if _, err := io.Copy(io.Discard, fh); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+	}
+
+	if manifest == nil {
+		return "", fmt.Errorf("%w: checksum manifest missing", ErrVerificationFailed)
+	}
+
+	for name, sum := range expected {
+		switch {
+		case seen[name] != sum:
+			return "", fmt.Errorf("%w: checksum mismatch for %s", ErrVerificationFailed, name)
+		case manifest[name] != sum:
+			return "", fmt.Errorf("%w: manifest mismatch for %s", ErrVerificationFailed, name)
+		}
+	}
+
+	if len(seen) != len(expected) || len(manifest) != len(expected) {
+		return "", fmt.Errorf("%w: unexpected entries found", ErrVerificationFailed)
+	}
+
+	return hex.EncodeToString(archiveHash.Sum(nil)), nil
+}
+
+// parseManifest reads a checksum manifest in sha256sum format.
+func parseManifest(r io.Reader) (map[string]string, error) {
+
+	manifest := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		sum, name, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok {
+			return nil, fmt.Errorf("malformed checksum manifest line: %q", scanner.Text())
+		}
+		manifest[name] = sum
+	}
+
+	return manifest, scanner.Err()
+}
+
+// writeChecksumFile records the checksum of the archive in a file alongside
+// it using sha256sum format.
+func writeChecksumFile(archivePath string, sum string) error {
+	content := fmt.Sprintf("%s  %s\n", sum, filepath.Base(archivePath))
+	return os.WriteFile(archivePath+".sha256", []byte(content), 0600)
+}
+
+// newCompressor returns a writer which compresses data using the specified
+// archive format and compression level. A level of 0 selects the default
+// level for the format.
+func newCompressor(w io.Writer, format string, level int) (io.WriteCloser, error) {
+
+	switch format {
+	case config.ArchiveFormatTarGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+
+	case config.ArchiveFormatTarZstd:
+		encoderLevel := zstd.SpeedDefault
+		if level != 0 {
+			encoderLevel = zstd.EncoderLevelFromZstd(level)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(encoderLevel))
+
+	default:
+		return nil, fmt.Errorf("unsupported archive format %q", format)
+	}
+}
+
+// newDecompressor returns a reader which decompresses data using the
+// specified archive format.
+func newDecompressor(r io.Reader, format string) (io.ReadCloser, error) {
+
+	switch format {
+	case config.ArchiveFormatTarGzip:
+		return gzip.NewReader(r)
+
+	case config.ArchiveFormatTarZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported archive format %q", format)
+	}
+}
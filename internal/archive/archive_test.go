@@ -0,0 +1,135 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+)
+
+func newTestFiles(t *testing.T, dir string, names ...string) matches.FileMatches {
+	t.Helper()
+
+	var files matches.FileMatches
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("archive test: "+name), 0600); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, matches.FileMatch{FileInfo: info, Path: path})
+	}
+
+	return files
+}
+
+func TestCreate(t *testing.T) {
+
+	for _, format := range []string{config.ArchiveFormatTarGzip, config.ArchiveFormatTarZstd} {
+		format := format
+		t.Run(format, func(t *testing.T) {
+
+			searchDir := t.TempDir()
+			archiveDir := t.TempDir()
+
+			c := config.NewDefaultConfig()
+			*c.ArchiveDir = archiveDir
+			*c.ArchiveFormat = format
+			*c.ArchiveLevel = 3
+			c.GetLogger().SetOutput(io.Discard)
+
+			files := newTestFiles(t, searchDir, "a.log", "sub/b.log")
+
+			// A file removed after matching must not be reported as
+			// archived.
+			missing := newTestFiles(t, searchDir, "missing.log")
+			if err := os.Remove(missing[0].Path); err != nil {
+				t.Fatal(err)
+			}
+			files = append(files, missing...)
+
+			results, err := Create(&c, searchDir, files)
+			if err != nil {
+				t.Fatalf("Create() failed: %s", err)
+			}
+
+			if len(results.Archived) != 2 {
+				t.Errorf("got %d archived files, want 2", len(results.Archived))
+			}
+
+			if len(results.Failed) != 1 || results.Failed[0].Path != missing[0].Path {
+				t.Errorf("got failed files %v, want %s", results.Failed, missing[0].Path)
+			}
+
+			if filepath.Base(results.ArchivePath) != Name(&c, searchDir) {
+				t.Errorf("got archive %s, want %s", results.ArchivePath, Name(&c, searchDir))
+			}
+
+			if _, err := os.Stat(results.ArchivePath + ".sha256"); err != nil {
+				t.Errorf("archive checksum file missing: %s", err)
+			}
+		})
+	}
+}
+
+func TestVerifyDetectsMismatch(t *testing.T) {
+
+	searchDir := t.TempDir()
+	archiveDir := t.TempDir()
+
+	c := config.NewDefaultConfig()
+	*c.ArchiveDir = archiveDir
+	c.GetLogger().SetOutput(io.Discard)
+
+	files := newTestFiles(t, searchDir, "a.log")
+
+	results, err := Create(&c, searchDir, files)
+	if err != nil {
+		t.Fatalf("Create() failed: %s", err)
+	}
+
+	expected := map[string]string{"a.log": "0000"}
+	if _, err := Verify(results.ArchivePath, c.GetArchiveFormat(), expected); !errors.Is(err, ErrVerificationFailed) {
+		t.Errorf("got error %v, want %v", err, ErrVerificationFailed)
+	}
+
+	// Truncate the archive to simulate a damaged write.
+	info, err := os.Stat(results.ArchivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(results.ArchivePath, info.Size()/2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Verify(results.ArchivePath, c.GetArchiveFormat(), nil); !errors.Is(err, ErrVerificationFailed) {
+		t.Errorf("got error %v, want %v", err, ErrVerificationFailed)
+	}
+}
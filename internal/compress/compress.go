@@ -0,0 +1,294 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compress compresses files in place, replacing each file with a
+// compressed copy that keeps the permissions and modification time of the
+// original.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/unlink"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ErrAlreadyCompressed indicates that a file was not compressed because it
+// is already in a compressed format.
+var ErrAlreadyCompressed = errors.New("file already compressed")
+
+// compressedExtensions lists extensions of common compressed file formats.
+// Files with one of these extensions are not compressed again.
+var compressedExtensions = []string{
+	".gz", ".tgz", ".zst", ".zstd", ".bz2", ".tbz2", ".xz", ".txz",
+	".lz", ".lz4", ".lzma", ".z", ".zip", ".7z", ".rar", ".br",
+}
+
+// compressedSignatures lists the leading bytes ("magic numbers") of common
+// compressed file formats. Files starting with one of these signatures are
+// not compressed again, regardless of extension.
+var compressedSignatures = [][]byte{
+	{0x1f, 0x8b},                         // gzip
+	{0x28, 0xb5, 0x2f, 0xfd},             // zstd
+	{0x42, 0x5a, 0x68},                   // bzip2
+	{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}, // xz
+	{0x04, 0x22, 0x4d, 0x18},             // lz4
+	{0x50, 0x4b, 0x03, 0x04},             // zip
+	{0x37, 0x7a, 0xbc, 0xaf, 0x27, 0x1c}, // 7z
+	{0x52, 0x61, 0x72, 0x21, 0x1a, 0x07}, // rar
+}
+
+// Result describes a file compressed in place.
+type Result struct {
+	// Path of the original (now removed) file.
+	Path string
+
+	// Path of the compressed copy which replaced the original.
+	CompressedPath string
+
+	// Size of the original file.
+	OriginalSize int64
+
+	// Size of the compressed copy.
+	CompressedSize int64
+}
+
+// Saved returns the number of bytes saved by compressing the file. The
+// value is negative if the compressed copy is larger than the original.
+func (r Result) Saved() int64 {
+	return r.OriginalSize - r.CompressedSize
+}
+
+// Extension returns the file extension appended to files compressed using
+// the specified format.
+func Extension(format string) string {
+	switch format {
+	case config.CompressZstd:
+		return ".zst"
+	default:
+		return ".gz"
+	}
+}
+
+// IsCompressed indicates whether the specified file is already compressed,
+// either based on its extension or on the leading bytes of its content.
+func IsCompressed(path string) (bool, error) {
+
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, compressedExt := range compressedExtensions {
+		if ext == compressedExt {
+			return true, nil
+		}
+	}
+
+	fh, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return false, err
+	}
+	defer fh.Close()
+
+	header := make([]byte, 8)
+	n, err := io.ReadFull(fh, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	for _, signature := range compressedSignatures {
+		if bytes.HasPrefix(header[:n], signature) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// File compresses the file at the specified path in place using the
+// compression format and level from the provided configuration. The
+// compressed copy is written alongside the original, flushed to disk and
+// given the permissions and modification time of the original. Only then is
+// the original removed. The original is read and removed relative to its
+// parent directory, opened below the search root in the same way as by
+// unlink.File, and only once confirmed to be a regular file matching the
+// provided identity; unlink.ErrChanged is returned (wrapped) otherwise.
+// Files which are already compressed are left as-is and ErrAlreadyCompressed
+// is returned. An existing compressed copy is never replaced.
+func File(path string, expected matches.Identity, config *config.Config) (result Result, err error) {
+
+	format := config.GetCompress()
+
+	parent, err := unlink.OpenParent(config.GetSearchRoot(), path)
+	if err != nil {
+		return Result{}, err
+	}
+	defer parent.Close()
+
+	in, err := parent.Open(os.O_RDONLY, expected)
+	if err != nil {
+		return Result{}, err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return Result{}, err
+	}
+
+	compressed, err := IsCompressed(path)
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to inspect %s: %w", path, err)
+	}
+	if compressed {
+		return Result{}, fmt.Errorf("%w: %s", ErrAlreadyCompressed, path)
+	}
+
+	destination := path + Extension(format)
+
+	size, err := compressFile(in, destination, info, format, config.GetCompressLevel())
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to compress %s: %w", path, err)
+	}
+
+	// Don't leave a copy alongside an original which could not be removed.
+	defer func() {
+		if err != nil {
+			_ = os.Remove(destination)
+		}
+	}()
+
+	// Confirm that the original was not replaced or written to while it was
+	// compressed. The original is closed first as open files cannot be
+	// removed on Windows.
+	_ = in.Close()
+	if err = parent.Check(matches.NewIdentity(info)); err != nil {
+		return Result{}, err
+	}
+
+	if err = parent.Remove(); err != nil {
+		return Result{}, fmt.Errorf("unable to remove %s after compression: %w", path, err)
+	}
+
+	return Result{
+		Path:           path,
+		CompressedPath: destination,
+		OriginalSize:   info.Size(),
+		CompressedSize: size,
+	}, nil
+}
+
+// compressFile writes a compressed copy of the content read from in to a new
+// destination file, flushes it to disk and applies the permissions,
+// modification time and (where supported) ownership of the original. The
+// size of the compressed copy is returned. A partial copy is removed if any
+// step fails; an existing destination file is left untouched.
+func compressFile(in *os.File, destination string, sourceInfo os.FileInfo, format string, level int) (size int64, err error) {
+
+	out, err := os.OpenFile(
+		filepath.Clean(destination),
+		os.O_WRONLY|os.O_CREATE|os.O_EXCL,
+		sourceInfo.Mode().Perm(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Don't leave a partial copy behind. This is only done once the copy
+	// was created by this call.
+	defer func() {
+		if err != nil {
+			_ = os.Remove(destination)
+		}
+	}()
+
+	compressor, err := newCompressor(out, format, level)
+	if err != nil {
+		_ = out.Close()
+		return 0, err
+	}
+
+	if _, err := io.Copy(compressor, in); err != nil {
+		_ = compressor.Close()
+		_ = out.Close()
+		return 0, err
+	}
+
+	if err := compressor.Close(); err != nil {
+		_ = out.Close()
+		return 0, err
+	}
+
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return 0, err
+	}
+
+	outInfo, err := out.Stat()
+	if err != nil {
+		_ = out.Close()
+		return 0, err
+	}
+
+	if err := out.Close(); err != nil {
+		return 0, err
+	}
+
+	// Apply the permissions explicitly as the mode used when creating the
+	// file is subject to the umask.
+	if err := os.Chmod(destination, sourceInfo.Mode().Perm()); err != nil {
+		return 0, err
+	}
+
+	if err := preserveOwner(destination, sourceInfo); err != nil {
+		return 0, err
+	}
+
+	if err := os.Chtimes(destination, sourceInfo.ModTime(), sourceInfo.ModTime()); err != nil {
+		return 0, err
+	}
+
+	return outInfo.Size(), nil
+}
+
+// newCompressor returns a writer which compresses data using the specified
+// format and level. A level of 0 selects the default level for the format.
+func newCompressor(w io.Writer, format string, level int) (io.WriteCloser, error) {
+
+	switch format {
+	case config.CompressGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+
+	case config.CompressZstd:
+		encoderLevel := zstd.SpeedDefault
+		if level != 0 {
+			encoderLevel = zstd.EncoderLevelFromZstd(level)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(encoderLevel))
+
+	default:
+		return nil, fmt.Errorf("unsupported compression format %q", format)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/klauspost/compress/zstd"
+)
+
+// identity returns the identity of the file at the specified path.
+func identity(t *testing.T, path string) matches.Identity {
+	t.Helper()
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return matches.NewIdentity(info)
+}
+
+func TestFile(t *testing.T) {
+
+	content := strings.Repeat("2020-01-01 00:00:00 INFO log line\n", 256)
+	modTime := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		format string
+		reader func(io.Reader) (io.Reader, error)
+	}{
+		{
+			format: config.CompressGzip,
+			reader: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		},
+		{
+			format: config.CompressZstd,
+			reader: func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+
+			dir := t.TempDir()
+
+			defaults := config.NewDefaultConfig()
+			*defaults.Compress = tt.format
+			c := defaults.ForPath(dir)
+
+			path := filepath.Join(dir, "app.log")
+			if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := File(path, identity(t, path), c)
+			if err != nil {
+				t.Fatalf("File() failed: %s", err)
+			}
+
+			if result.CompressedPath != path+Extension(tt.format) {
+				t.Errorf("got compressed path %s, want %s", result.CompressedPath, path+Extension(tt.format))
+			}
+
+			if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("original file %s still present after compression", path)
+			}
+
+			info, err := os.Stat(result.CompressedPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !info.ModTime().Equal(modTime) {
+				t.Errorf("got modification time %s, want %s", info.ModTime(), modTime)
+			}
+
+			if info.Mode().Perm() != 0640 {
+				t.Errorf("got permissions %o, want %o", info.Mode().Perm(), 0640)
+			}
+
+			if result.Saved() <= 0 || result.CompressedSize != info.Size() {
+				t.Errorf("unexpected sizes in result: %+v", result)
+			}
+
+			fh, err := os.Open(result.CompressedPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fh.Close()
+
+			r, err := tt.reader(fh)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decompressed, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(decompressed) != content {
+				t.Error("decompressed content does not match original")
+			}
+
+			// Compressing the compressed copy again is refused.
+			if _, err := File(result.CompressedPath, identity(t, result.CompressedPath), c); !errors.Is(err, ErrAlreadyCompressed) {
+				t.Errorf("got error %v, want %v", err, ErrAlreadyCompressed)
+			}
+		})
+	}
+}
+
+func TestFileKeepsExistingDestination(t *testing.T) {
+
+	dir := t.TempDir()
+	defaults := config.NewDefaultConfig()
+	c := defaults.ForPath(dir)
+
+	path := filepath.Join(dir, "app.log")
+	existing := path + Extension(c.GetCompress())
+
+	files := map[string]string{
+		path:     "2020-01-01 00:00:00 INFO log line\n",
+		existing: "previously compressed copy",
+	}
+	for file, content := range files {
+		if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := File(path, identity(t, path), c); err == nil {
+		t.Fatal("File() succeeded with existing compressed copy, want error")
+	}
+
+	for file, content := range files {
+		got, err := os.ReadFile(file)
+		if err != nil || string(got) != content {
+			t.Errorf("%s modified: %q, %v", file, got, err)
+		}
+	}
+}
+
+func TestIsCompressed(t *testing.T) {
+
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    bool
+	}{
+		{name: "plain.log", content: []byte("plain text"), want: false},
+		{name: "empty.log", content: nil, want: false},
+		{name: "rotated.log.gz", content: []byte("extension only"), want: true},
+		{name: "rotated.log.1", content: []byte{0x1f, 0x8b, 0x08, 0x00}, want: true},
+		{name: "rotated.log.2", content: []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.content, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := IsCompressed(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -23,9 +23,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/atc0005/elbow/internal/journal"
 	"github.com/atc0005/elbow/internal/logging"
+	"github.com/atc0005/elbow/internal/throttle"
 
 	"github.com/alexflint/go-arg"
 	"github.com/pelletier/go-toml/v2"
@@ -48,13 +52,56 @@ type AppMetadata struct {
 // FileHandling represents options specific to how this application
 // handles files.
 type FileHandling struct {
-	FilePattern    *string  `toml:"pattern" arg:"--pattern,env:ELBOW_FILE_PATTERN" help:"Substring pattern to compare filenames against. Wildcards are not supported."`
-	FileExtensions []string `toml:"file_extensions" arg:"--extensions,env:ELBOW_EXTENSIONS" help:"Limit search to specified file extensions. Specify as space separated list to match multiple required extensions. Comparisons are performed case-insensitively."`
-	FileAge        *int     `toml:"file_age" arg:"--age,env:ELBOW_FILE_AGE" help:"Limit search to files that are the specified number of days old or older."`
-	NumFilesToKeep *int     `toml:"files_to_keep" arg:"--keep,env:ELBOW_KEEP" help:"Keep specified number of matching files per provided path."`
-	KeepOldest     *bool    `toml:"keep_oldest" arg:"--keep-old,env:ELBOW_KEEP_OLD" help:"Keep oldest files instead of newer per provided path."`
-	Remove         *bool    `toml:"remove" arg:"--remove,env:ELBOW_REMOVE" help:"Remove matched files per provided path."`
-	IgnoreErrors   *bool    `toml:"ignore_errors" arg:"--ignore-errors,env:ELBOW_IGNORE_ERRORS" help:"Ignore errors encountered during file removal."`
+	FilePattern     *string  `toml:"pattern" arg:"--pattern,env:ELBOW_FILE_PATTERN" help:"Substring pattern to compare filenames against. Wildcards are not supported."`
+	FileExtensions  []string `toml:"file_extensions" arg:"--extensions,env:ELBOW_EXTENSIONS" help:"Limit search to specified file extensions. Specify as space separated list to match multiple required extensions. Comparisons are performed case-insensitively."`
+	Normalization   *string  `toml:"unicode_normalization" arg:"--unicode-normalization,env:ELBOW_UNICODE_NORMALIZATION" help:"Unicode normalization form (nfc, nfd or nfkc) applied to filename patterns, extensions and filenames before comparison. Filenames are compared as-is if not specified."`
+	CaseFold        *bool    `toml:"case_fold" arg:"--case-fold,env:ELBOW_CASE_FOLD" help:"Apply full Unicode case folding to filename patterns, extensions and filenames before comparison."`
+	FileAge         *int     `toml:"file_age" arg:"--age,env:ELBOW_FILE_AGE" help:"Limit search to files that are the specified number of days old or older."`
+	RelativeAge     *bool    `toml:"relative_age" arg:"--relative-age,env:ELBOW_RELATIVE_AGE" help:"Measure file age relative to the newest matching file per provided path instead of the current time."`
+	CalendarAge     *bool    `toml:"calendar_age" arg:"--calendar-age,env:ELBOW_CALENDAR_AGE" help:"Align file age thresholds to midnight so that files last modified before the day the specified number of days back are eligible, regardless of the time of day the application runs."`
+	Timezone        *string  `toml:"timezone" arg:"--timezone,env:ELBOW_TIMEZONE" help:"IANA time zone (e.g., America/Chicago) used to calculate and display file age thresholds. The local time zone is used if not specified."`
+	AsOf            *string  `toml:"as_of" arg:"--as-of,env:ELBOW_AS_OF" help:"Evaluate file age as of the specified RFC 3339 timestamp (e.g., 2026-01-02T15:04:05Z) instead of the current time. Useful in combination with the default dry-run behavior to preview the results of a future run."`
+	MaxStaleness    *int     `toml:"max_staleness" arg:"--max-staleness,env:ELBOW_MAX_STALENESS" help:"Skip pruning a path (and log a warning) if its newest matching file is older than the specified number of days."`
+	NumFilesToKeep  *int     `toml:"files_to_keep" arg:"--keep,env:ELBOW_KEEP" help:"Keep specified number of matching files per provided path."`
+	KeepOldest      *bool    `toml:"keep_oldest" arg:"--keep-old,env:ELBOW_KEEP_OLD" help:"Keep oldest files instead of newer per provided path."`
+	Remove          *bool    `toml:"remove" arg:"--remove,env:ELBOW_REMOVE" help:"Remove matched files per provided path."`
+	IgnoreErrors    *bool    `toml:"ignore_errors" arg:"--ignore-errors,env:ELBOW_IGNORE_ERRORS" help:"Ignore errors encountered during file removal."`
+	ContentPattern  *string  `toml:"content_pattern" arg:"--content-pattern,env:ELBOW_CONTENT_PATTERN" help:"Limit search to files whose content contains the specified literal string. Only the inspected range of each file is read."`
+	ContentRegex    *string  `toml:"content_regex" arg:"--content-regex,env:ELBOW_CONTENT_REGEX" help:"Limit search to files whose content matches the specified regular expression. Only the inspected range of each file is read."`
+	ContentBytes    *int     `toml:"content_bytes" arg:"--content-bytes,env:ELBOW_CONTENT_BYTES" help:"Number of bytes read from each file when evaluating content patterns."`
+	ContentFromEnd  *bool    `toml:"content_from_end" arg:"--content-from-end,env:ELBOW_CONTENT_FROM_END" help:"Inspect the last bytes of each file instead of the first when evaluating content patterns."`
+	ContentTypes    []string `toml:"content_types" arg:"--content-type,env:ELBOW_CONTENT_TYPES" help:"Limit search to files whose detected content type matches one of the specified MIME types (e.g., application/zip, text/*). Types are detected by sniffing the first bytes of each file."`
+	SettleSeconds   *int     `toml:"settle_seconds" arg:"--settle-seconds,env:ELBOW_SETTLE_SECONDS" help:"Re-check the size and modification time of files selected for removal after waiting the specified number of seconds. Files that changed are skipped."`
+	StabilityState  *string  `toml:"stability_state_file" arg:"--stability-state-file,env:ELBOW_STABILITY_STATE_FILE" help:"Optional file used to record the size and modification time of matched files between runs. Files that changed since (or were not seen by) the previous run are skipped."`
+	QuarantineDir   *string  `toml:"quarantine_dir" arg:"--quarantine-dir,env:ELBOW_QUARANTINE_DIR" help:"Move files into the specified quarantine directory instead of removing them. Files are stored per run below their original path and recorded in a manifest so that they can be restored via the restore subcommand."`
+	QuarantineDays  *int     `toml:"quarantine_retention" arg:"--quarantine-retention,env:ELBOW_QUARANTINE_RETENTION" help:"Purge runs from the quarantine directory once they are the specified number of days old. 0 disables purging."`
+	ArchiveDir      *string  `toml:"archive_dir" arg:"--archive-dir,env:ELBOW_ARCHIVE_DIR" help:"Bundle files into a compressed archive in the specified directory before removing them. Files that fail to archive are not removed."`
+	ArchiveFormat   *string  `toml:"archive_format" arg:"--archive-format,env:ELBOW_ARCHIVE_FORMAT" help:"Format used when archiving files before removal."`
+	ArchiveLevel    *int     `toml:"archive_level" arg:"--archive-level,env:ELBOW_ARCHIVE_LEVEL" help:"Compression level used when archiving files before removal. 0 selects the default level for the archive format."`
+	Compress        *string  `toml:"compress" arg:"--compress,env:ELBOW_COMPRESS" help:"Compress files in place using the specified format instead of removing them. The compressed copy keeps the permissions and modification time of the original. Files that are already compressed are skipped."`
+	CompressLevel   *int     `toml:"compress_level" arg:"--compress-level,env:ELBOW_COMPRESS_LEVEL" help:"Compression level used when compressing files in place. 0 selects the default level for the compression format."`
+	ShredPasses     *int     `toml:"shred_passes" arg:"--shred-passes,env:ELBOW_SHRED_PASSES" help:"Overwrite the content of files the specified number of times before removing them. Files are flushed to disk after each pass, then truncated and renamed before removal to obscure the original name. 0 disables shredding."`
+	Truncate        *bool    `toml:"truncate" arg:"--truncate,env:ELBOW_TRUNCATE" help:"Truncate files instead of removing them, reclaiming their space even if the file is still held open by another process."`
+	TruncateOpen    *bool    `toml:"truncate_open" arg:"--truncate-open,env:ELBOW_TRUNCATE_OPEN" help:"Truncate files instead of removing them if they are detected as held open by another process. Only supported on Linux."`
+	KeepBytes       *int     `toml:"truncate_keep_bytes" arg:"--truncate-keep-bytes,env:ELBOW_TRUNCATE_KEEP_BYTES" help:"Keep the specified number of bytes at the end of each truncated file."`
+	KeepLines       *int     `toml:"truncate_keep_lines" arg:"--truncate-keep-lines,env:ELBOW_TRUNCATE_KEEP_LINES" help:"Keep the specified number of lines at the end of each truncated file."`
+	ExecCommand     *string  `toml:"exec_command" arg:"--exec-command,env:ELBOW_EXEC_COMMAND" help:"Command run for each file once it was removed. Arguments are split on whitespace and may reference {{.Path}}, {{.Name}}, {{.Dir}}, {{.Size}}, {{.ModTime}} and {{.RunID}}. Files for which the command fails are reported as failed."`
+	ExecTimeout     *int     `toml:"exec_timeout" arg:"--exec-timeout,env:ELBOW_EXEC_TIMEOUT" help:"Maximum number of seconds an exec command may run before it is killed and treated as failed. 0 disables the timeout."`
+	ExecConcurrency *int     `toml:"exec_concurrency" arg:"--exec-concurrency,env:ELBOW_EXEC_CONCURRENCY" help:"Number of exec commands run at the same time (also limited by the number of workers)."`
+	Workers         *int     `toml:"workers" arg:"--workers,env:ELBOW_WORKERS" help:"Number of files removed (or otherwise handled) at the same time per path. Unless errors are ignored, no further files are processed once a file fails."`
+	DeleteRate      *int     `toml:"delete_rate" arg:"--delete-rate,env:ELBOW_DELETE_RATE" help:"Maximum number of files removed (or otherwise handled) per second. 0 disables the limit."`
+	DeleteBytesRate *int     `toml:"delete_bytes_rate" arg:"--delete-bytes-rate,env:ELBOW_DELETE_BYTES_RATE" help:"Maximum number of bytes of files removed (or otherwise handled) per second. 0 disables the limit."`
+	IdleIO          *bool    `toml:"idle_io" arg:"--idle-io,env:ELBOW_IDLE_IO" help:"Place this application in the idle I/O scheduling class at startup so that it only uses disk time not needed by other processes. Only supported on Linux."`
+	Nice            *int     `toml:"nice" arg:"--nice,env:ELBOW_NICE" help:"Nice value (1-19) applied to this application at startup to lower its CPU scheduling priority. 0 leaves the priority unchanged. Only supported on Linux."`
+	MaxRemovals     *int     `toml:"max_removals" arg:"--max-removals,env:ELBOW_MAX_REMOVALS" help:"Skip pruning a path if more than the specified number of files are planned for removal. 0 disables the limit."`
+	MaxRemovalSize  *int     `toml:"max_removal_size" arg:"--max-removal-size,env:ELBOW_MAX_REMOVAL_SIZE" help:"Skip pruning a path if the files planned for removal total more than the specified number of bytes. 0 disables the limit."`
+	MaxRemovalPct   *int     `toml:"max_removal_percent" arg:"--max-removal-percent,env:ELBOW_MAX_REMOVAL_PERCENT" help:"Skip pruning a path if more than the specified percentage of all files found below the path are planned for removal. 0 disables the limit."`
+	KeepLastMatch   *bool    `toml:"keep_last_match" arg:"--keep-last-match,env:ELBOW_KEEP_LAST_MATCH" help:"Skip pruning a path if all matching files are planned for removal, so that the last matching file is never removed."`
+	GuardScope      *string  `toml:"guard_scope" arg:"--guard-scope,env:ELBOW_GUARD_SCOPE" help:"Skip pruning only the path (path) or the path and all further paths (run) if a safety limit is exceeded."`
+	Force           *bool    `toml:"-" arg:"--force" help:"Prune paths even if a safety limit is exceeded. May only be set via command-line flag."`
+	Interactive     *bool    `toml:"-" arg:"--interactive,env:ELBOW_INTERACTIVE" help:"Review the files planned for removal below each path and confirm or deselect them before they are removed. Requires a terminal. May not be set via configuration file."`
+	JournalDir      *string  `toml:"journal_dir" arg:"--journal-dir,env:ELBOW_JOURNAL_DIR" help:"Directory holding a journal of the files planned for removal and handled by each run. Interrupted runs can be finished as planned via the resume subcommand."`
+	PinAttribute    *string  `toml:"pin_attribute" arg:"--pin-attribute,env:ELBOW_PIN_ATTRIBUTE" help:"Extended attribute used to pin files against removal. Pinned files still count toward the number of files to keep."`
 }
 
 // Search represents options specific to controlling how this application
@@ -62,6 +109,15 @@ type FileHandling struct {
 type Search struct {
 	Paths           []string `toml:"paths" arg:"--paths,env:ELBOW_PATHS" help:"List of comma or space-separated paths to process."`
 	RecursiveSearch *bool    `toml:"recursive_search" arg:"--recurse,env:ELBOW_RECURSE" help:"Perform recursive search into subdirectories per provided path."`
+
+	ProtectSymlinkTargets *bool    `toml:"protect_symlink_targets" arg:"--protect-symlink-targets,env:ELBOW_PROTECT_SYMLINK_TARGETS" help:"Protect matching files that are the target of a symlink found in the provided path or in any additional symlink location."`
+	StatRate              *int     `toml:"stat_rate" arg:"--stat-rate,env:ELBOW_STAT_RATE" help:"Maximum number of files examined per second while searching paths. 0 disables the limit."`
+	SymlinkLocations      []string `toml:"symlink_locations" arg:"--symlink-locations,env:ELBOW_SYMLINK_LOCATIONS" help:"Additional list of comma or space-separated paths searched for symlinks when protecting symlink targets."`
+
+	// AllowProtectedPaths permits processing paths on the built-in list of
+	// protected system locations. This may only be enabled via
+	// configuration file so that it is always an explicit, recorded choice.
+	AllowProtectedPaths *bool `toml:"allow_protected_paths" arg:"-"`
 }
 
 // Logging represents options specific to how this application handles
@@ -74,6 +130,73 @@ type Logging struct {
 	UseSyslog     *bool   `toml:"use_syslog" arg:"--use-syslog,env:ELBOW_USE_SYSLOG" help:"Log messages to syslog in addition to other outputs. Not supported on Windows."`
 }
 
+// Hooks represents options specific to the commands this application runs
+// before and after pruning each path.
+type Hooks struct {
+	PreHook     *string `toml:"pre_hook" arg:"--pre-hook,env:ELBOW_PRE_HOOK" help:"Command run before pruning each path. Arguments are split on whitespace and may reference {{.Path}} and {{.RunID}}. A non-zero exit status vetoes pruning the path."`
+	PostHook    *string `toml:"post_hook" arg:"--post-hook,env:ELBOW_POST_HOOK" help:"Command run after pruning each path. Arguments are split on whitespace and may reference {{.Path}} and {{.RunID}}. The results for the path are provided as JSON on standard input."`
+	HookTimeout *int    `toml:"hook_timeout" arg:"--hook-timeout,env:ELBOW_HOOK_TIMEOUT" help:"Maximum number of seconds a hook may run before it is killed and treated as failed. 0 disables the timeout."`
+}
+
+// PathSettings represents settings applied to a single path, overriding the
+// settings applied to all other paths. Path settings may only be provided
+// via configuration file.
+type PathSettings struct {
+	Path          string  `toml:"path"`
+	ArchiveDir    *string `toml:"archive_dir"`
+	ArchiveFormat *string `toml:"archive_format"`
+	ArchiveLevel  *int    `toml:"archive_level"`
+	ShredPasses   *int    `toml:"shred_passes"`
+}
+
+// Stage represents a single lifecycle stage. Matching files are handled by
+// the stage with the greatest age they meet, so that compress, move and
+// delete stages can be applied to a single set of matches within one run.
+// Stages may only be provided via configuration file.
+type Stage struct {
+	Action      string `toml:"action"`
+	Age         int    `toml:"age"`
+	Destination string `toml:"destination"`
+	Format      string `toml:"format"`
+}
+
+// PinCmd represents the options for the pin subcommand. This subcommand sets
+// the pin extended attribute on the specified files, protecting them from
+// removal.
+type PinCmd struct {
+	Until string   `arg:"--until" help:"Pin files only until the specified RFC 3339 timestamp (e.g., 2026-01-02T15:04:05Z). Files are pinned indefinitely if not specified."`
+	Files []string `arg:"positional,required" help:"Files to pin."`
+}
+
+// UnpinCmd represents the options for the unpin subcommand. This subcommand
+// removes the pin extended attribute from the specified files.
+type UnpinCmd struct {
+	Files []string `arg:"positional,required" help:"Files to unpin."`
+}
+
+// RestoreCmd represents the options for the restore subcommand. This
+// subcommand moves quarantined files back to their original location.
+type RestoreCmd struct {
+	Runs  []string `arg:"--run" help:"Restore all files quarantined by the specified run IDs."`
+	Files []string `arg:"positional" help:"Original paths of quarantined files to restore."`
+}
+
+// ResumeCmd represents the options for the resume subcommand. This
+// subcommand finishes interrupted runs recorded in the journal directory.
+type ResumeCmd struct {
+	Runs []string `arg:"positional" help:"IDs of interrupted runs to resume. All interrupted runs are resumed if not specified."`
+}
+
+// Commands represents the optional subcommands supported by this
+// application. If no subcommand is specified the default behavior of
+// evaluating (and optionally pruning) the requested paths is used.
+type Commands struct {
+	Pin     *PinCmd     `toml:"-" arg:"subcommand:pin" help:"Pin files against removal by setting an extended attribute."`
+	Unpin   *UnpinCmd   `toml:"-" arg:"subcommand:unpin" help:"Remove the pin extended attribute from files."`
+	Restore *RestoreCmd `toml:"-" arg:"subcommand:restore" help:"Restore quarantined files to their original location."`
+	Resume  *ResumeCmd  `toml:"-" arg:"subcommand:resume" help:"Finish interrupted runs exactly as planned, using the settings recorded in the journal."`
+}
+
 // Config represents a collection of configuration settings for this
 // application. Config is created as early as possible upon application
 // startup.
@@ -91,6 +214,15 @@ type Config struct {
 	FileHandling `toml:"filehandling"`
 	Logging      `toml:"logging"`
 	Search       `toml:"search"`
+	Hooks        `toml:"hooks"`
+	Commands     `toml:"-"`
+
+	// Settings which override other settings for specific paths.
+	PathSettings []PathSettings `toml:"path_settings" arg:"-"`
+
+	// Lifecycle stages applied to matching files, replacing the single
+	// removal action.
+	Stages []Stage `toml:"stages" arg:"-"`
 
 	// Embedded to allow for easier carrying of "handles" between functions
 	// TODO: Confirm that this is both needed and that it doesn't violate
@@ -100,6 +232,33 @@ type Config struct {
 	logger        *logrus.Logger `toml:"-" arg:"-"`
 	flagParser    *arg.Parser    `toml:"-" arg:"-"`
 
+	// Compiled form of the ContentRegex field, created on first use.
+	contentRegexp *regexp.Regexp `toml:"-" arg:"-"`
+
+	// Loaded form of the Timezone field, created on first use.
+	location *time.Location `toml:"-" arg:"-"`
+
+	// Identifier for this application run, created on first use.
+	runID string `toml:"-" arg:"-"`
+
+	// Identifier of the application run resuming an interrupted run, set
+	// for configurations resuming a run.
+	resumeRunID string `toml:"-" arg:"-"`
+
+	// Rate limits applied to this application run, created on first use.
+	throttle *throttle.Throttle `toml:"-" arg:"-"`
+
+	// Journal of this application run, set once created.
+	journal *journal.Journal `toml:"-" arg:"-"`
+
+	// Search path currently being processed, set for per-path copies of
+	// the configuration.
+	searchRoot string `toml:"-" arg:"-"`
+
+	// Destination directory used when moving files, set for the move
+	// lifecycle stage.
+	moveDir string `toml:"-" arg:"-"`
+
 	// Path to (optional) configuration file
 	ConfigFile *string `toml:"config_file" arg:"--config-file,env:ELBOW_CONFIG_FILE" help:"Full path to optional TOML-formatted configuration file. See config.example.toml for a starter template."`
 }
@@ -115,17 +274,65 @@ func NewDefaultConfig() Config {
 	defaultAppDescription := c.GetAppDescription()
 	defaultAppURL := c.GetAppURL()
 	defaultFilePattern := c.GetFilePattern()
+	defaultNormalization := c.GetUnicodeNormalization()
+	defaultCaseFold := c.GetCaseFold()
 	defaultFileAge := c.GetFileAge()
+	defaultRelativeAge := c.GetRelativeAge()
+	defaultCalendarAge := c.GetCalendarAge()
+	defaultTimezone := c.GetTimezone()
+	defaultAsOf := c.GetAsOf()
+	defaultMaxStaleness := c.GetMaxStaleness()
 	defaultNumFilesToKeep := c.GetNumFilesToKeep()
 	defaultKeepOldest := c.GetKeepOldest()
 	defaultRemove := c.GetRemove()
 	defaultIgnoreErrors := c.GetIgnoreErrors()
+	defaultContentPattern := c.GetContentPattern()
+	defaultContentRegex := c.GetContentRegex()
+	defaultContentBytes := c.GetContentBytes()
+	defaultContentFromEnd := c.GetContentFromEnd()
+	defaultSettleSeconds := c.GetSettleSeconds()
+	defaultStabilityState := c.GetStabilityStateFile()
+	defaultQuarantineDir := c.GetQuarantineDir()
+	defaultQuarantineDays := c.GetQuarantineRetention()
+	defaultArchiveDir := c.GetArchiveDir()
+	defaultArchiveFormat := c.GetArchiveFormat()
+	defaultArchiveLevel := c.GetArchiveLevel()
+	defaultCompress := c.GetCompress()
+	defaultCompressLevel := c.GetCompressLevel()
+	defaultShredPasses := c.GetShredPasses()
+	defaultTruncate := c.GetTruncate()
+	defaultTruncateOpen := c.GetTruncateOpen()
+	defaultKeepBytes := c.GetTruncateKeepBytes()
+	defaultKeepLines := c.GetTruncateKeepLines()
+	defaultExecCommand := c.GetExecCommand()
+	defaultExecTimeout := c.GetExecTimeout()
+	defaultExecConcurrency := c.GetExecConcurrency()
+	defaultWorkers := c.GetWorkers()
+	defaultDeleteRate := c.GetDeleteRate()
+	defaultDeleteBytesRate := c.GetDeleteBytesRate()
+	defaultIdleIO := c.GetIdleIO()
+	defaultNice := c.GetNice()
+	defaultMaxRemovals := c.GetMaxRemovals()
+	defaultMaxRemovalSize := c.GetMaxRemovalSize()
+	defaultMaxRemovalPercent := c.GetMaxRemovalPercent()
+	defaultKeepLastMatch := c.GetKeepLastMatch()
+	defaultGuardScope := c.GetGuardScope()
+	defaultForce := c.GetForce()
+	defaultInteractive := c.GetInteractive()
+	defaultJournalDir := c.GetJournalDir()
+	defaultPinAttribute := c.GetPinAttribute()
 	defaultRecursiveSearch := c.GetRecursiveSearch()
+	defaultProtectSymlinkTargets := c.GetProtectSymlinkTargets()
+	defaultStatRate := c.GetStatRate()
+	defaultAllowProtectedPaths := c.GetAllowProtectedPaths()
 	defaultLogLevel := c.GetLogLevel()
 	defaultLogFormat := c.GetLogFormat()
 	defaultLogFilePath := c.GetLogFilePath()
 	defaultConsoleOutput := c.GetConsoleOutput()
 	defaultUseSyslog := c.GetUseSyslog()
+	defaultPreHook := c.GetPreHook()
+	defaultPostHook := c.GetPostHook()
+	defaultHookTimeout := c.GetHookTimeout()
 	defaultConfigFile := c.GetConfigFile()
 
 	defaultConfig := Config{
@@ -138,11 +345,53 @@ func NewDefaultConfig() Config {
 		FileHandling: FileHandling{
 			FilePattern: &defaultFilePattern,
 			//FileExtensions: &fileExtensions,
-			FileAge:        &defaultFileAge,
-			NumFilesToKeep: &defaultNumFilesToKeep,
-			KeepOldest:     &defaultKeepOldest,
-			Remove:         &defaultRemove,
-			IgnoreErrors:   &defaultIgnoreErrors,
+			Normalization:   &defaultNormalization,
+			CaseFold:        &defaultCaseFold,
+			FileAge:         &defaultFileAge,
+			RelativeAge:     &defaultRelativeAge,
+			CalendarAge:     &defaultCalendarAge,
+			Timezone:        &defaultTimezone,
+			AsOf:            &defaultAsOf,
+			MaxStaleness:    &defaultMaxStaleness,
+			NumFilesToKeep:  &defaultNumFilesToKeep,
+			KeepOldest:      &defaultKeepOldest,
+			Remove:          &defaultRemove,
+			IgnoreErrors:    &defaultIgnoreErrors,
+			ContentPattern:  &defaultContentPattern,
+			ContentRegex:    &defaultContentRegex,
+			ContentBytes:    &defaultContentBytes,
+			ContentFromEnd:  &defaultContentFromEnd,
+			SettleSeconds:   &defaultSettleSeconds,
+			StabilityState:  &defaultStabilityState,
+			QuarantineDir:   &defaultQuarantineDir,
+			QuarantineDays:  &defaultQuarantineDays,
+			ArchiveDir:      &defaultArchiveDir,
+			ArchiveFormat:   &defaultArchiveFormat,
+			ArchiveLevel:    &defaultArchiveLevel,
+			Compress:        &defaultCompress,
+			CompressLevel:   &defaultCompressLevel,
+			ShredPasses:     &defaultShredPasses,
+			Truncate:        &defaultTruncate,
+			TruncateOpen:    &defaultTruncateOpen,
+			KeepBytes:       &defaultKeepBytes,
+			KeepLines:       &defaultKeepLines,
+			ExecCommand:     &defaultExecCommand,
+			ExecTimeout:     &defaultExecTimeout,
+			ExecConcurrency: &defaultExecConcurrency,
+			Workers:         &defaultWorkers,
+			DeleteRate:      &defaultDeleteRate,
+			DeleteBytesRate: &defaultDeleteBytesRate,
+			IdleIO:          &defaultIdleIO,
+			Nice:            &defaultNice,
+			MaxRemovals:     &defaultMaxRemovals,
+			MaxRemovalSize:  &defaultMaxRemovalSize,
+			MaxRemovalPct:   &defaultMaxRemovalPercent,
+			KeepLastMatch:   &defaultKeepLastMatch,
+			GuardScope:      &defaultGuardScope,
+			Force:           &defaultForce,
+			Interactive:     &defaultInteractive,
+			JournalDir:      &defaultJournalDir,
+			PinAttribute:    &defaultPinAttribute,
 		},
 		Logging: Logging{
 			LogLevel:      &defaultLogLevel,
@@ -153,7 +402,15 @@ func NewDefaultConfig() Config {
 		},
 		Search: Search{
 			//Paths: ,
-			RecursiveSearch: &defaultRecursiveSearch,
+			RecursiveSearch:       &defaultRecursiveSearch,
+			ProtectSymlinkTargets: &defaultProtectSymlinkTargets,
+			StatRate:              &defaultStatRate,
+			AllowProtectedPaths:   &defaultAllowProtectedPaths,
+		},
+		Hooks: Hooks{
+			PreHook:     &defaultPreHook,
+			PostHook:    &defaultPostHook,
+			HookTimeout: &defaultHookTimeout,
 		},
 		ConfigFile: &defaultConfigFile,
 	}
@@ -427,6 +684,18 @@ func (c *Config) LoadConfigFile(fileHandle io.Reader) error {
 	return toml.Unmarshal(configFile, c)
 }
 
+// Snapshot returns the settings of the configuration in TOML format, as
+// recorded in the journal of a run so that the run can be resumed with the
+// same settings.
+func (c *Config) Snapshot() (string, error) {
+	content, err := toml.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
 // Description provides an overview as part of the application Help output
 func (c Config) Description() string {
 
@@ -454,7 +723,7 @@ func (c Config) Version() string {
 // formatting if using the TextFormatter logrus formatter.
 func (c *Config) String() string {
 
-	return fmt.Sprintf("AppName=%q, AppDescription=%q, AppVersion=%q, AppURL=%q, FilePattern=%q, FileExtensions=%q, Paths=%v, RecursiveSearch=%t, FileAge=%d, NumFilesToKeep=%d, KeepOldest=%t, Remove=%t, IgnoreErrors=%t, LogFormat=%q, LogFilePath=%q, ConfigFile=%q, ConsoleOutput=%q, LogLevel=%q, UseSyslog=%t, logger=%v, flagParser=%v,  logFileHandle=%v",
+	return fmt.Sprintf("AppName=%q, AppDescription=%q, AppVersion=%q, AppURL=%q, FilePattern=%q, FileExtensions=%q, UnicodeNormalization=%q, CaseFold=%t, Paths=%v, RecursiveSearch=%t, ProtectSymlinkTargets=%t, SymlinkLocations=%v, AllowProtectedPaths=%t, FileAge=%d, RelativeAge=%t, CalendarAge=%t, Timezone=%q, AsOf=%q, MaxStaleness=%d, NumFilesToKeep=%d, KeepOldest=%t, Remove=%t, IgnoreErrors=%t, ContentPattern=%q, ContentRegex=%q, ContentBytes=%d, ContentFromEnd=%t, ContentTypes=%q, SettleSeconds=%d, StabilityStateFile=%q, QuarantineDir=%q, QuarantineRetention=%d, ArchiveDir=%q, ArchiveFormat=%q, ArchiveLevel=%d, Compress=%q, CompressLevel=%d, ShredPasses=%d, Truncate=%t, TruncateOpen=%t, TruncateKeepBytes=%d, TruncateKeepLines=%d, ExecCommand=%q, ExecTimeout=%d, ExecConcurrency=%d, Workers=%d, DeleteRate=%d, DeleteBytesRate=%d, StatRate=%d, IdleIO=%t, Nice=%d, MaxRemovals=%d, MaxRemovalSize=%d, MaxRemovalPercent=%d, KeepLastMatch=%t, GuardScope=%q, Force=%t, Interactive=%t, JournalDir=%q, PathSettings=%d, Stages=%d, PinAttribute=%q, LogFormat=%q, LogFilePath=%q, ConfigFile=%q, ConsoleOutput=%q, LogLevel=%q, UseSyslog=%t, PreHook=%q, PostHook=%q, HookTimeout=%d, logger=%v, flagParser=%v,  logFileHandle=%v",
 
 		c.GetAppName(),
 		c.GetAppDescription(),
@@ -462,19 +731,71 @@ func (c *Config) String() string {
 		c.GetAppURL(),
 		c.GetFilePattern(),
 		c.GetFileExtensions(),
+		c.GetUnicodeNormalization(),
+		c.GetCaseFold(),
 		c.GetPaths(),
 		c.GetRecursiveSearch(),
+		c.GetProtectSymlinkTargets(),
+		c.GetSymlinkLocations(),
+		c.GetAllowProtectedPaths(),
 		c.GetFileAge(),
+		c.GetRelativeAge(),
+		c.GetCalendarAge(),
+		c.GetTimezone(),
+		c.GetAsOf(),
+		c.GetMaxStaleness(),
 		c.GetNumFilesToKeep(),
 		c.GetKeepOldest(),
 		c.GetRemove(),
 		c.GetIgnoreErrors(),
+		c.GetContentPattern(),
+		c.GetContentRegex(),
+		c.GetContentBytes(),
+		c.GetContentFromEnd(),
+		c.GetContentTypes(),
+		c.GetSettleSeconds(),
+		c.GetStabilityStateFile(),
+		c.GetQuarantineDir(),
+		c.GetQuarantineRetention(),
+		c.GetArchiveDir(),
+		c.GetArchiveFormat(),
+		c.GetArchiveLevel(),
+		c.GetCompress(),
+		c.GetCompressLevel(),
+		c.GetShredPasses(),
+		c.GetTruncate(),
+		c.GetTruncateOpen(),
+		c.GetTruncateKeepBytes(),
+		c.GetTruncateKeepLines(),
+		c.GetExecCommand(),
+		c.GetExecTimeout(),
+		c.GetExecConcurrency(),
+		c.GetWorkers(),
+		c.GetDeleteRate(),
+		c.GetDeleteBytesRate(),
+		c.GetStatRate(),
+		c.GetIdleIO(),
+		c.GetNice(),
+		c.GetMaxRemovals(),
+		c.GetMaxRemovalSize(),
+		c.GetMaxRemovalPercent(),
+		c.GetKeepLastMatch(),
+		c.GetGuardScope(),
+		c.GetForce(),
+		c.GetInteractive(),
+		c.GetJournalDir(),
+		len(c.PathSettings),
+		len(c.Stages),
+		c.GetPinAttribute(),
 		c.GetLogFormat(),
 		c.GetLogFilePath(),
 		c.GetConfigFile(),
 		c.GetConsoleOutput(),
 		c.GetLogLevel(),
 		c.GetUseSyslog(),
+		c.GetPreHook(),
+		c.GetPostHook(),
+		c.GetHookTimeout(),
 		c.GetLogger(),
 		c.GetFlagParser(),
 		c.GetLogFileHandle(),
@@ -53,4 +53,74 @@ const (
 	// DefaultAppURL is the website where users can learn more about the
 	// application, submit problem reports, etc.
 	DefaultAppURL string = "https://github.com/atc0005/elbow"
+
+	// DefaultContentBytes is the number of bytes read from each file when
+	// evaluating content patterns. This keeps the I/O cost of content
+	// matching bounded regardless of file size.
+	DefaultContentBytes int = 4096
+
+	// DefaultPinAttribute is the extended attribute used to pin files
+	// against removal. A value of "1" pins a file indefinitely while an RFC
+	// 3339 timestamp pins a file until that time.
+	DefaultPinAttribute string = "user.elbow.keep"
+
+	// DefaultArchiveFormat is the format used when archiving files before
+	// removal.
+	DefaultArchiveFormat string = ArchiveFormatTarGzip
+
+	// DefaultExecConcurrency is the number of exec commands run at the same
+	// time.
+	DefaultExecConcurrency int = 1
+
+	// DefaultWorkers is the number of files the removal action is applied
+	// to at the same time.
+	DefaultWorkers int = 1
+
+	// DefaultGuardScope is the scope of the safety limits applied to files
+	// planned for removal.
+	DefaultGuardScope string = GuardScopePath
+)
+
+// Scopes of the safety limits applied to files planned for removal.
+const (
+	// Pruning of the path which exceeds a limit is skipped.
+	GuardScopePath string = "path"
+
+	// Pruning of the path which exceeds a limit and all further paths is
+	// skipped.
+	GuardScopeRun string = "run"
+)
+
+// Subcommands supported by this application.
+const (
+	SubcommandPin     string = "pin"
+	SubcommandUnpin   string = "unpin"
+	SubcommandRestore string = "restore"
+	SubcommandResume  string = "resume"
+)
+
+// Unicode normalization forms supported for filename comparisons.
+const (
+	NormalizationNFC  string = "nfc"
+	NormalizationNFD  string = "nfd"
+	NormalizationNFKC string = "nfkc"
+)
+
+// Archive formats supported when archiving files before removal.
+const (
+	ArchiveFormatTarGzip string = "tar.gz"
+	ArchiveFormatTarZstd string = "tar.zst"
+)
+
+// Actions supported by lifecycle stages.
+const (
+	StageActionCompress string = "compress"
+	StageActionMove     string = "move"
+	StageActionDelete   string = "delete"
+)
+
+// Formats supported when compressing files in place.
+const (
+	CompressGzip string = "gzip"
+	CompressZstd string = "zstd"
 )
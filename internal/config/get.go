@@ -17,10 +17,16 @@
 package config
 
 import (
+	"crypto/rand"
+	"fmt"
 	"os"
+	"regexp"
+	"time"
 
 	"github.com/alexflint/go-arg"
+	"github.com/atc0005/elbow/internal/journal"
 	"github.com/atc0005/elbow/internal/logging"
+	"github.com/atc0005/elbow/internal/throttle"
 	"github.com/sirupsen/logrus"
 )
 
@@ -85,6 +91,24 @@ func (c *Config) GetFileExtensions() []string {
 	return c.FileExtensions
 }
 
+// GetUnicodeNormalization returns the Normalization field if it's non-nil,
+// zero value otherwise.
+func (c *Config) GetUnicodeNormalization() string {
+	if c == nil || c.Normalization == nil {
+		return ""
+	}
+	return *c.Normalization
+}
+
+// GetCaseFold returns the CaseFold field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetCaseFold() bool {
+	if c == nil || c.CaseFold == nil {
+		return false
+	}
+	return *c.CaseFold
+}
+
 // GetFileAge returns the FileAge field if it's non-nil, app default value
 // otherwise
 func (c *Config) GetFileAge() int {
@@ -94,6 +118,79 @@ func (c *Config) GetFileAge() int {
 	return *c.FileAge
 }
 
+// GetRelativeAge returns the RelativeAge field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetRelativeAge() bool {
+	if c == nil || c.RelativeAge == nil {
+		return false
+	}
+	return *c.RelativeAge
+}
+
+// GetCalendarAge returns the CalendarAge field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetCalendarAge() bool {
+	if c == nil || c.CalendarAge == nil {
+		return false
+	}
+	return *c.CalendarAge
+}
+
+// GetTimezone returns the Timezone field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetTimezone() string {
+	if c == nil || c.Timezone == nil {
+		return ""
+	}
+	return *c.Timezone
+}
+
+// GetAsOf returns the AsOf field if it's non-nil, zero value otherwise.
+func (c *Config) GetAsOf() string {
+	if c == nil || c.AsOf == nil {
+		return ""
+	}
+	return *c.AsOf
+}
+
+// Now returns the reference time used when evaluating file age. This is the
+// timestamp provided via the AsOf field if set, otherwise the current time.
+func (c *Config) Now() time.Time {
+	if c.GetAsOf() != "" {
+		asOf, err := time.Parse(time.RFC3339, c.GetAsOf())
+		if err == nil {
+			return asOf
+		}
+	}
+	return time.Now()
+}
+
+// GetLocation returns the location for the Timezone field. The location is
+// loaded on first use and cached for later calls. The local time zone is
+// returned if the Timezone field is not set or fails to load.
+func (c *Config) GetLocation() *time.Location {
+	if c == nil || c.GetTimezone() == "" {
+		return time.Local
+	}
+	if c.location == nil || c.location.String() != c.GetTimezone() {
+		loc, err := time.LoadLocation(c.GetTimezone())
+		if err != nil {
+			return time.Local
+		}
+		c.location = loc
+	}
+	return c.location
+}
+
+// GetMaxStaleness returns the MaxStaleness field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetMaxStaleness() int {
+	if c == nil || c.MaxStaleness == nil {
+		return 0
+	}
+	return *c.MaxStaleness
+}
+
 // GetNumFilesToKeep returns the NumFilesToKeep field if it's non-nil, zero
 // value otherwise.
 func (c *Config) GetNumFilesToKeep() int {
@@ -130,6 +227,491 @@ func (c *Config) GetIgnoreErrors() bool {
 	return *c.IgnoreErrors
 }
 
+// GetContentPattern returns the ContentPattern field if it's non-nil, zero
+// value otherwise.
+func (c *Config) GetContentPattern() string {
+	if c == nil || c.ContentPattern == nil {
+		return ""
+	}
+	return *c.ContentPattern
+}
+
+// GetContentRegex returns the ContentRegex field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetContentRegex() string {
+	if c == nil || c.ContentRegex == nil {
+		return ""
+	}
+	return *c.ContentRegex
+}
+
+// GetContentRegexp returns the compiled form of the ContentRegex field. The
+// expression is compiled on first use and cached for later calls. nil is
+// returned if the ContentRegex field is not set or fails to compile.
+func (c *Config) GetContentRegexp() *regexp.Regexp {
+	if c == nil || c.GetContentRegex() == "" {
+		return nil
+	}
+	if c.contentRegexp == nil || c.contentRegexp.String() != c.GetContentRegex() {
+		re, err := regexp.Compile(c.GetContentRegex())
+		if err != nil {
+			return nil
+		}
+		c.contentRegexp = re
+	}
+	return c.contentRegexp
+}
+
+// GetContentBytes returns the ContentBytes field if it's non-nil, app default
+// value otherwise.
+func (c *Config) GetContentBytes() int {
+	if c == nil || c.ContentBytes == nil {
+		return DefaultContentBytes
+	}
+	return *c.ContentBytes
+}
+
+// GetContentFromEnd returns the ContentFromEnd field if it's non-nil, zero
+// value otherwise.
+func (c *Config) GetContentFromEnd() bool {
+	if c == nil || c.ContentFromEnd == nil {
+		return false
+	}
+	return *c.ContentFromEnd
+}
+
+// GetContentTypes returns the ContentTypes field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetContentTypes() []string {
+	if c == nil || c.ContentTypes == nil {
+		return nil
+	}
+	return c.ContentTypes
+}
+
+// GetSettleSeconds returns the SettleSeconds field if it's non-nil, zero
+// value otherwise.
+func (c *Config) GetSettleSeconds() int {
+	if c == nil || c.SettleSeconds == nil {
+		return 0
+	}
+	return *c.SettleSeconds
+}
+
+// GetStabilityStateFile returns the StabilityState field if it's non-nil,
+// zero value otherwise.
+func (c *Config) GetStabilityStateFile() string {
+	if c == nil || c.StabilityState == nil {
+		return ""
+	}
+	return *c.StabilityState
+}
+
+// GetQuarantineDir returns the QuarantineDir field if it's non-nil, zero
+// value otherwise.
+func (c *Config) GetQuarantineDir() string {
+	if c == nil || c.QuarantineDir == nil {
+		return ""
+	}
+	return *c.QuarantineDir
+}
+
+// GetQuarantineRetention returns the QuarantineDays field if it's non-nil,
+// zero value otherwise.
+func (c *Config) GetQuarantineRetention() int {
+	if c == nil || c.QuarantineDays == nil {
+		return 0
+	}
+	return *c.QuarantineDays
+}
+
+// GetArchiveDir returns the ArchiveDir field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetArchiveDir() string {
+	if c == nil || c.ArchiveDir == nil {
+		return ""
+	}
+	return *c.ArchiveDir
+}
+
+// GetArchiveFormat returns the ArchiveFormat field if it's non-nil, app
+// default value otherwise.
+func (c *Config) GetArchiveFormat() string {
+	if c == nil || c.ArchiveFormat == nil {
+		return DefaultArchiveFormat
+	}
+	return *c.ArchiveFormat
+}
+
+// GetArchiveLevel returns the ArchiveLevel field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetArchiveLevel() int {
+	if c == nil || c.ArchiveLevel == nil {
+		return 0
+	}
+	return *c.ArchiveLevel
+}
+
+// GetCompress returns the Compress field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetCompress() string {
+	if c == nil || c.Compress == nil {
+		return ""
+	}
+	return *c.Compress
+}
+
+// GetCompressLevel returns the CompressLevel field if it's non-nil, zero
+// value otherwise.
+func (c *Config) GetCompressLevel() int {
+	if c == nil || c.CompressLevel == nil {
+		return 0
+	}
+	return *c.CompressLevel
+}
+
+// GetShredPasses returns the ShredPasses field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetShredPasses() int {
+	if c == nil || c.ShredPasses == nil {
+		return 0
+	}
+	return *c.ShredPasses
+}
+
+// GetTruncate returns the Truncate field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetTruncate() bool {
+	if c == nil || c.Truncate == nil {
+		return false
+	}
+	return *c.Truncate
+}
+
+// GetTruncateOpen returns the TruncateOpen field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetTruncateOpen() bool {
+	if c == nil || c.TruncateOpen == nil {
+		return false
+	}
+	return *c.TruncateOpen
+}
+
+// GetTruncateKeepBytes returns the KeepBytes field if it's non-nil, zero
+// value otherwise.
+func (c *Config) GetTruncateKeepBytes() int {
+	if c == nil || c.KeepBytes == nil {
+		return 0
+	}
+	return *c.KeepBytes
+}
+
+// GetTruncateKeepLines returns the KeepLines field if it's non-nil, zero
+// value otherwise.
+func (c *Config) GetTruncateKeepLines() int {
+	if c == nil || c.KeepLines == nil {
+		return 0
+	}
+	return *c.KeepLines
+}
+
+// GetExecCommand returns the ExecCommand field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetExecCommand() string {
+	if c == nil || c.ExecCommand == nil {
+		return ""
+	}
+	return *c.ExecCommand
+}
+
+// GetExecTimeout returns the ExecTimeout field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetExecTimeout() int {
+	if c == nil || c.ExecTimeout == nil {
+		return 0
+	}
+	return *c.ExecTimeout
+}
+
+// GetExecConcurrency returns the ExecConcurrency field if it's non-nil, app
+// default value otherwise.
+func (c *Config) GetExecConcurrency() int {
+	if c == nil || c.ExecConcurrency == nil {
+		return DefaultExecConcurrency
+	}
+	return *c.ExecConcurrency
+}
+
+// GetWorkers returns the Workers field if it's non-nil, app default value
+// otherwise.
+func (c *Config) GetWorkers() int {
+	if c == nil || c.Workers == nil {
+		return DefaultWorkers
+	}
+	return *c.Workers
+}
+
+// GetDeleteRate returns the DeleteRate field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetDeleteRate() int {
+	if c == nil || c.DeleteRate == nil {
+		return 0
+	}
+	return *c.DeleteRate
+}
+
+// GetDeleteBytesRate returns the DeleteBytesRate field if it's non-nil, zero
+// value otherwise.
+func (c *Config) GetDeleteBytesRate() int {
+	if c == nil || c.DeleteBytesRate == nil {
+		return 0
+	}
+	return *c.DeleteBytesRate
+}
+
+// GetStatRate returns the StatRate field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetStatRate() int {
+	if c == nil || c.StatRate == nil {
+		return 0
+	}
+	return *c.StatRate
+}
+
+// GetIdleIO returns the IdleIO field if it's non-nil, zero value otherwise.
+func (c *Config) GetIdleIO() bool {
+	if c == nil || c.IdleIO == nil {
+		return false
+	}
+	return *c.IdleIO
+}
+
+// GetNice returns the Nice field if it's non-nil, zero value otherwise.
+func (c *Config) GetNice() int {
+	if c == nil || c.Nice == nil {
+		return 0
+	}
+	return *c.Nice
+}
+
+// GetMaxRemovals returns the MaxRemovals field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetMaxRemovals() int {
+	if c == nil || c.MaxRemovals == nil {
+		return 0
+	}
+	return *c.MaxRemovals
+}
+
+// GetMaxRemovalSize returns the MaxRemovalSize field if it's non-nil, zero
+// value otherwise.
+func (c *Config) GetMaxRemovalSize() int {
+	if c == nil || c.MaxRemovalSize == nil {
+		return 0
+	}
+	return *c.MaxRemovalSize
+}
+
+// GetMaxRemovalPercent returns the MaxRemovalPct field if it's non-nil,
+// zero value otherwise.
+func (c *Config) GetMaxRemovalPercent() int {
+	if c == nil || c.MaxRemovalPct == nil {
+		return 0
+	}
+	return *c.MaxRemovalPct
+}
+
+// GetKeepLastMatch returns the KeepLastMatch field if it's non-nil, zero
+// value otherwise.
+func (c *Config) GetKeepLastMatch() bool {
+	if c == nil || c.KeepLastMatch == nil {
+		return false
+	}
+	return *c.KeepLastMatch
+}
+
+// GetGuardScope returns the GuardScope field if it's non-nil, app default
+// value otherwise.
+func (c *Config) GetGuardScope() string {
+	if c == nil || c.GuardScope == nil {
+		return DefaultGuardScope
+	}
+	return *c.GuardScope
+}
+
+// GetForce returns the Force field if it's non-nil, zero value otherwise.
+func (c *Config) GetForce() bool {
+	if c == nil || c.Force == nil {
+		return false
+	}
+	return *c.Force
+}
+
+// GetInteractive returns the Interactive field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetInteractive() bool {
+	if c == nil || c.Interactive == nil {
+		return false
+	}
+	return *c.Interactive
+}
+
+// GetThrottle returns the rate limits applied to this application run. The
+// limits are created on first use and shared by all copies of the
+// configuration made afterwards. nil is returned if no limits are
+// configured.
+func (c *Config) GetThrottle() *throttle.Throttle {
+	if c == nil {
+		return nil
+	}
+	if c.throttle == nil {
+		c.throttle = throttle.New(c.GetDeleteRate(), c.GetDeleteBytesRate(), c.GetStatRate())
+	}
+	return c.throttle
+}
+
+// GetJournalDir returns the JournalDir field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetJournalDir() string {
+	if c == nil || c.JournalDir == nil {
+		return ""
+	}
+	return *c.JournalDir
+}
+
+// GetJournal returns the journal of this application run, or nil if no
+// journal was set. The journal is shared by all copies of the configuration
+// made after it was set.
+func (c *Config) GetJournal() *journal.Journal {
+	if c == nil {
+		return nil
+	}
+	return c.journal
+}
+
+// SetJournal sets the journal of this application run. This must be called
+// before copies of the configuration are made for individual paths.
+func (c *Config) SetJournal(j *journal.Journal) {
+	c.journal = j
+}
+
+// GetPreHook returns the PreHook field if it's non-nil, zero value otherwise.
+func (c *Config) GetPreHook() string {
+	if c == nil || c.PreHook == nil {
+		return ""
+	}
+	return *c.PreHook
+}
+
+// GetPostHook returns the PostHook field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetPostHook() string {
+	if c == nil || c.PostHook == nil {
+		return ""
+	}
+	return *c.PostHook
+}
+
+// GetHookTimeout returns the HookTimeout field if it's non-nil, zero value
+// otherwise.
+func (c *Config) GetHookTimeout() int {
+	if c == nil || c.HookTimeout == nil {
+		return 0
+	}
+	return *c.HookTimeout
+}
+
+// GetMoveDir returns the directory files are moved into by the move
+// lifecycle stage, zero value otherwise.
+func (c *Config) GetMoveDir() string {
+	if c == nil {
+		return ""
+	}
+	return c.moveDir
+}
+
+// GetSearchRoot returns the search path a per-path copy of the
+// configuration was created for, zero value otherwise.
+func (c *Config) GetSearchRoot() string {
+	if c == nil {
+		return ""
+	}
+	return c.searchRoot
+}
+
+// GetStageMoveDirs returns the destination directories of move lifecycle
+// stages.
+func (c *Config) GetStageMoveDirs() []string {
+	if c == nil {
+		return nil
+	}
+
+	var dirs []string
+	for _, stage := range c.Stages {
+		if stage.Action == StageActionMove && stage.Destination != "" {
+			dirs = append(dirs, stage.Destination)
+		}
+	}
+	return dirs
+}
+
+// GetResumeRunID returns the identifier of the application run resuming an
+// interrupted run, or an empty string if the configuration does not resume
+// a run.
+func (c *Config) GetResumeRunID() string {
+	if c == nil {
+		return ""
+	}
+	return c.resumeRunID
+}
+
+// GetRunID returns the identifier for this application run. The identifier
+// is generated on first use from the current time and a random suffix so
+// that identifiers sort in the order runs were started.
+func (c *Config) GetRunID() string {
+	if c == nil {
+		return ""
+	}
+	if c.runID == "" {
+		timestamp := time.Now().UTC().Format("20060102T150405Z")
+		suffix := make([]byte, 4)
+		if _, err := rand.Read(suffix); err != nil {
+			c.runID = fmt.Sprintf("%s-%d", timestamp, os.Getpid())
+			return c.runID
+		}
+		c.runID = fmt.Sprintf("%s-%x", timestamp, suffix)
+	}
+	return c.runID
+}
+
+// GetPinAttribute returns the PinAttribute field if it's non-nil, app default
+// value otherwise.
+func (c *Config) GetPinAttribute() string {
+	if c == nil || c.PinAttribute == nil {
+		return DefaultPinAttribute
+	}
+	return *c.PinAttribute
+}
+
+// GetSubcommand returns the name of the requested subcommand, or an empty
+// string if no subcommand was requested.
+func (c *Config) GetSubcommand() string {
+	switch {
+	case c == nil:
+		return ""
+	case c.Pin != nil:
+		return SubcommandPin
+	case c.Unpin != nil:
+		return SubcommandUnpin
+	case c.Restore != nil:
+		return SubcommandRestore
+	case c.Resume != nil:
+		return SubcommandResume
+	default:
+		return ""
+	}
+}
+
 // GetPaths returns the Paths field if it's non-nil, app default value
 // otherwise
 func (c *Config) GetPaths() []string {
@@ -148,6 +730,33 @@ func (c *Config) GetRecursiveSearch() bool {
 	return *c.RecursiveSearch
 }
 
+// GetProtectSymlinkTargets returns the ProtectSymlinkTargets field if it's
+// non-nil, zero value otherwise.
+func (c *Config) GetProtectSymlinkTargets() bool {
+	if c == nil || c.ProtectSymlinkTargets == nil {
+		return false
+	}
+	return *c.ProtectSymlinkTargets
+}
+
+// GetSymlinkLocations returns the SymlinkLocations field if it's non-nil,
+// zero value otherwise.
+func (c *Config) GetSymlinkLocations() []string {
+	if c == nil || c.SymlinkLocations == nil {
+		return nil
+	}
+	return c.SymlinkLocations
+}
+
+// GetAllowProtectedPaths returns the AllowProtectedPaths field if it's
+// non-nil, zero value otherwise.
+func (c *Config) GetAllowProtectedPaths() bool {
+	if c == nil || c.AllowProtectedPaths == nil {
+		return false
+	}
+	return *c.AllowProtectedPaths
+}
+
 // GetLogLevel returns the LogLevel field if it's non-nil, app default value
 // otherwise
 func (c *Config) GetLogLevel() string {
@@ -87,10 +87,38 @@ func MergeConfig(destination *Config, source Config) error {
 		*destination.FilePattern = *source.FilePattern
 	}
 
+	if source.Normalization != nil {
+		*destination.Normalization = *source.Normalization
+	}
+
+	if source.CaseFold != nil {
+		*destination.CaseFold = *source.CaseFold
+	}
+
 	if source.FileAge != nil {
 		*destination.FileAge = *source.FileAge
 	}
 
+	if source.RelativeAge != nil {
+		*destination.RelativeAge = *source.RelativeAge
+	}
+
+	if source.CalendarAge != nil {
+		*destination.CalendarAge = *source.CalendarAge
+	}
+
+	if source.Timezone != nil {
+		*destination.Timezone = *source.Timezone
+	}
+
+	if source.AsOf != nil {
+		*destination.AsOf = *source.AsOf
+	}
+
+	if source.MaxStaleness != nil {
+		*destination.MaxStaleness = *source.MaxStaleness
+	}
+
 	if source.NumFilesToKeep != nil {
 		*destination.NumFilesToKeep = *source.NumFilesToKeep
 	}
@@ -107,10 +135,206 @@ func MergeConfig(destination *Config, source Config) error {
 		*destination.IgnoreErrors = *source.IgnoreErrors
 	}
 
+	if source.ContentPattern != nil {
+		*destination.ContentPattern = *source.ContentPattern
+	}
+
+	if source.ContentRegex != nil {
+		*destination.ContentRegex = *source.ContentRegex
+	}
+
+	if source.ContentBytes != nil {
+		*destination.ContentBytes = *source.ContentBytes
+	}
+
+	if source.ContentFromEnd != nil {
+		*destination.ContentFromEnd = *source.ContentFromEnd
+	}
+
+	if source.ContentTypes != nil {
+		destination.ContentTypes = source.ContentTypes
+	}
+
+	if source.SettleSeconds != nil {
+		*destination.SettleSeconds = *source.SettleSeconds
+	}
+
+	if source.StabilityState != nil {
+		*destination.StabilityState = *source.StabilityState
+	}
+
+	if source.QuarantineDir != nil {
+		*destination.QuarantineDir = *source.QuarantineDir
+	}
+
+	if source.QuarantineDays != nil {
+		*destination.QuarantineDays = *source.QuarantineDays
+	}
+
+	if source.ArchiveDir != nil {
+		*destination.ArchiveDir = *source.ArchiveDir
+	}
+
+	if source.ArchiveFormat != nil {
+		*destination.ArchiveFormat = *source.ArchiveFormat
+	}
+
+	if source.ArchiveLevel != nil {
+		*destination.ArchiveLevel = *source.ArchiveLevel
+	}
+
+	if source.Compress != nil {
+		*destination.Compress = *source.Compress
+	}
+
+	if source.CompressLevel != nil {
+		*destination.CompressLevel = *source.CompressLevel
+	}
+
+	if source.ShredPasses != nil {
+		*destination.ShredPasses = *source.ShredPasses
+	}
+
+	if source.Truncate != nil {
+		*destination.Truncate = *source.Truncate
+	}
+
+	if source.TruncateOpen != nil {
+		*destination.TruncateOpen = *source.TruncateOpen
+	}
+
+	if source.KeepBytes != nil {
+		*destination.KeepBytes = *source.KeepBytes
+	}
+
+	if source.KeepLines != nil {
+		*destination.KeepLines = *source.KeepLines
+	}
+
+	if source.ExecCommand != nil {
+		*destination.ExecCommand = *source.ExecCommand
+	}
+
+	if source.ExecTimeout != nil {
+		*destination.ExecTimeout = *source.ExecTimeout
+	}
+
+	if source.ExecConcurrency != nil {
+		*destination.ExecConcurrency = *source.ExecConcurrency
+	}
+
+	if source.Workers != nil {
+		*destination.Workers = *source.Workers
+	}
+
+	if source.DeleteRate != nil {
+		*destination.DeleteRate = *source.DeleteRate
+	}
+
+	if source.DeleteBytesRate != nil {
+		*destination.DeleteBytesRate = *source.DeleteBytesRate
+	}
+
+	if source.IdleIO != nil {
+		*destination.IdleIO = *source.IdleIO
+	}
+
+	if source.Nice != nil {
+		*destination.Nice = *source.Nice
+	}
+
+	if source.MaxRemovals != nil {
+		*destination.MaxRemovals = *source.MaxRemovals
+	}
+
+	if source.MaxRemovalSize != nil {
+		*destination.MaxRemovalSize = *source.MaxRemovalSize
+	}
+
+	if source.MaxRemovalPct != nil {
+		*destination.MaxRemovalPct = *source.MaxRemovalPct
+	}
+
+	if source.KeepLastMatch != nil {
+		*destination.KeepLastMatch = *source.KeepLastMatch
+	}
+
+	if source.GuardScope != nil {
+		*destination.GuardScope = *source.GuardScope
+	}
+
+	if source.Force != nil {
+		*destination.Force = *source.Force
+	}
+
+	if source.Interactive != nil {
+		*destination.Interactive = *source.Interactive
+	}
+
+	if source.PathSettings != nil {
+		destination.PathSettings = source.PathSettings
+	}
+
+	if source.Stages != nil {
+		destination.Stages = source.Stages
+	}
+
+	if source.JournalDir != nil {
+		*destination.JournalDir = *source.JournalDir
+	}
+
+	if source.PinAttribute != nil {
+		*destination.PinAttribute = *source.PinAttribute
+	}
+
+	if source.Pin != nil {
+		destination.Pin = source.Pin
+	}
+
+	if source.Unpin != nil {
+		destination.Unpin = source.Unpin
+	}
+
+	if source.Restore != nil {
+		destination.Restore = source.Restore
+	}
+
+	if source.Resume != nil {
+		destination.Resume = source.Resume
+	}
+
 	if source.RecursiveSearch != nil {
 		*destination.RecursiveSearch = *source.RecursiveSearch
 	}
 
+	if source.ProtectSymlinkTargets != nil {
+		*destination.ProtectSymlinkTargets = *source.ProtectSymlinkTargets
+	}
+
+	if source.StatRate != nil {
+		*destination.StatRate = *source.StatRate
+	}
+
+	if source.SymlinkLocations != nil {
+		destination.SymlinkLocations = source.SymlinkLocations
+	}
+
+	if source.AllowProtectedPaths != nil {
+		*destination.AllowProtectedPaths = *source.AllowProtectedPaths
+	}
+
+	if source.PreHook != nil {
+		*destination.PreHook = *source.PreHook
+	}
+
+	if source.PostHook != nil {
+		*destination.PostHook = *source.PostHook
+	}
+
+	if source.HookTimeout != nil {
+		*destination.HookTimeout = *source.HookTimeout
+	}
+
 	if source.LogLevel != nil {
 		*destination.LogLevel = *source.LogLevel
 	}
@@ -0,0 +1,123 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ForPath returns a copy of the configuration with any settings specific to
+// the provided path applied. The copy shares the logger and other handles of
+// the original configuration and records the path so that files can be
+// placed relative to it. If no settings are specific to the path the copy
+// otherwise matches the original.
+func (c *Config) ForPath(path string) *Config {
+
+	// Generate the run ID and rate limits before copying so that all copies
+	// share them.
+	c.GetRunID()
+	c.GetThrottle()
+
+	pathConfig := *c
+	pathConfig.searchRoot = path
+
+	for _, settings := range c.PathSettings {
+		if filepath.Clean(settings.Path) != filepath.Clean(path) {
+			continue
+		}
+
+		if settings.ArchiveDir != nil {
+			pathConfig.ArchiveDir = settings.ArchiveDir
+		}
+
+		if settings.ArchiveFormat != nil {
+			pathConfig.ArchiveFormat = settings.ArchiveFormat
+		}
+
+		if settings.ArchiveLevel != nil {
+			pathConfig.ArchiveLevel = settings.ArchiveLevel
+		}
+
+		if settings.ShredPasses != nil {
+			pathConfig.ShredPasses = settings.ShredPasses
+		}
+	}
+
+	return &pathConfig
+}
+
+// ForStage returns a copy of the configuration which applies the provided
+// lifecycle stage to files found below the root search path. The age of the
+// stage replaces the file age setting and the action of the stage replaces
+// the removal action.
+func (c *Config) ForStage(stage Stage, root string) *Config {
+
+	// Generate the run ID and rate limits before copying so that all copies
+	// share them.
+	c.GetRunID()
+	c.GetThrottle()
+
+	stageConfig := *c
+
+	age := stage.Age
+	stageConfig.FileAge = &age
+
+	var compress string
+	stageConfig.Compress = &compress
+	stageConfig.QuarantineDir = nil
+	stageConfig.ArchiveDir = nil
+	stageConfig.moveDir = ""
+	stageConfig.searchRoot = root
+
+	switch stage.Action {
+	case StageActionCompress:
+		compress = stage.Format
+		if compress == "" {
+			compress = CompressGzip
+		}
+	case StageActionMove:
+		stageConfig.moveDir = stage.Destination
+	}
+
+	return &stageConfig
+}
+
+// ForResume returns a configuration which resumes the interrupted run with
+// the specified ID, applying the settings recorded for the run instead of
+// the current settings. The configuration shares the logger and other
+// handles of the original configuration and uses the ID of the interrupted
+// run so that files are placed (e.g., quarantined) as the run would have.
+// The ID of the resuming run is recorded as well so that files which cannot
+// be shared with the interrupted run (e.g., archives) are named apart.
+func (c *Config) ForResume(runID string, settings string) (*Config, error) {
+
+	var resumeConfig Config
+	if err := resumeConfig.LoadConfigFile(strings.NewReader(settings)); err != nil {
+		return nil, fmt.Errorf("unable to load settings recorded for run %s: %w", runID, err)
+	}
+
+	resumeConfig.AppMetadata = c.AppMetadata
+	resumeConfig.logger = c.logger
+	resumeConfig.logFileHandle = c.logFileHandle
+	resumeConfig.flagParser = c.flagParser
+	resumeConfig.runID = runID
+	resumeConfig.resumeRunID = c.GetRunID()
+
+	return &resumeConfig, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// canonicalSearchPath returns an absolute form of the specified path with
+// all symlinks and ".." elements resolved. Symlinks are resolved before ".."
+// elements are applied so that the result reflects the directory actually
+// processed. If the path cannot be resolved (e.g., it does not exist), the
+// deepest ancestor which can be resolved is used and the remaining elements
+// are applied lexically; missing paths are reported once processed.
+func canonicalSearchPath(path string) (string, error) {
+
+	if !filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		// filepath.Join is intentionally avoided as it would apply ".."
+		// elements before symlinks are resolved.
+		path = wd + string(filepath.Separator) + path
+	}
+
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved, nil
+	}
+
+	dir := path
+	var remainder []string
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return filepath.Clean(path), nil
+		}
+
+		remainder = append([]string{filepath.Base(dir)}, remainder...)
+		dir = parent
+
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{resolved}, remainder...)...), nil
+		}
+	}
+}
+
+// protectedLocation reports whether the specified canonical path is one of
+// the built-in protected system locations or located below a protected
+// system tree. The matching protected location is returned along with the
+// result.
+func protectedLocation(canonicalPath string) (string, bool) {
+
+	path := foldPath(filepath.Clean(canonicalPath))
+
+	for _, tree := range protectedTrees() {
+		folded := foldPath(filepath.Clean(tree))
+		if path == folded ||
+			strings.HasPrefix(path, strings.TrimSuffix(folded, string(filepath.Separator))+string(filepath.Separator)) {
+			return tree, true
+		}
+	}
+
+	for _, location := range protectedLocations() {
+		if path == foldPath(filepath.Clean(location)) {
+			return location, true
+		}
+	}
+
+	return "", false
+}
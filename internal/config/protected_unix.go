@@ -0,0 +1,86 @@
+//go:build !windows
+// +build !windows
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"runtime"
+	"strings"
+)
+
+// protectedTrees returns system locations which may neither be processed
+// themselves nor have any directory below them processed.
+func protectedTrees() []string {
+	return []string{
+		"/bin",
+		"/boot",
+		"/dev",
+		"/etc",
+		"/lib",
+		"/lib32",
+		"/lib64",
+		"/libx32",
+		"/proc",
+		"/sbin",
+		"/sys",
+		"/usr",
+
+		// macOS
+		"/System",
+		"/private/etc",
+	}
+}
+
+// protectedLocations returns system locations which may not be processed
+// themselves, but commonly hold directories which are safe to process
+// (e.g., /var holds /var/log/app).
+func protectedLocations() []string {
+	return []string{
+		"/",
+		"/home",
+		"/media",
+		"/mnt",
+		"/opt",
+		"/root",
+		"/run",
+		"/srv",
+		"/tmp",
+		"/var",
+		"/var/lib",
+
+		// macOS
+		"/Applications",
+		"/Library",
+		"/Users",
+		"/Volumes",
+		"/private",
+		"/private/tmp",
+		"/private/var",
+	}
+}
+
+// foldPath returns the specified path in the form used to compare it against
+// protected locations. The default macOS filesystem is case-insensitive, so
+// paths are compared without regard to case there.
+func foldPath(path string) string {
+	if runtime.GOOS == "darwin" {
+		return strings.ToLower(path)
+	}
+	return path
+}
@@ -0,0 +1,78 @@
+//go:build windows
+// +build windows
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// systemDrive returns the drive holding the Windows installation.
+func systemDrive() string {
+	if drive := os.Getenv("SystemDrive"); drive != "" {
+		return drive
+	}
+	return "C:"
+}
+
+// protectedTrees returns system locations which may neither be processed
+// themselves nor have any directory below them processed.
+func protectedTrees() []string {
+
+	trees := []string{
+		systemDrive() + `\Windows`,
+		systemDrive() + `\Program Files`,
+		systemDrive() + `\Program Files (x86)`,
+	}
+
+	for _, env := range []string{"SystemRoot", "ProgramFiles", "ProgramFiles(x86)", "ProgramW6432"} {
+		if location := os.Getenv(env); location != "" {
+			trees = append(trees, location)
+		}
+	}
+
+	return trees
+}
+
+// protectedLocations returns system locations which may not be processed
+// themselves, but commonly hold directories which are safe to process
+// (e.g., ProgramData holds application log directories).
+func protectedLocations() []string {
+
+	locations := []string{
+		systemDrive() + `\`,
+		systemDrive() + `\ProgramData`,
+		systemDrive() + `\Users`,
+	}
+
+	for _, env := range []string{"ProgramData", "USERPROFILE"} {
+		if location := os.Getenv(env); location != "" {
+			locations = append(locations, location)
+		}
+	}
+
+	return locations
+}
+
+// foldPath returns the specified path in the form used to compare it against
+// protected locations. Windows paths are compared without regard to case.
+func foldPath(path string) string {
+	return strings.ToLower(path)
+}
@@ -18,6 +18,11 @@ package config
 
 import (
 	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/atc0005/elbow/internal/logging"
 )
@@ -57,10 +62,35 @@ func (c Config) Validate() error {
 	// 	return false, fmt.Errorf("file extensions option not configured")
 	// }
 
-	if c.Paths == nil {
+	// Paths are not used by subcommands, so only require them when the
+	// default behavior of evaluating paths is requested.
+	if c.Paths == nil && c.GetSubcommand() == "" {
 		return fmt.Errorf("one or more paths not provided")
 	}
 
+	if err := c.validateProtectedPaths(); err != nil {
+		return err
+	}
+
+	if c.Pin != nil && c.Pin.Until != "" {
+		if _, err := time.Parse(time.RFC3339, c.Pin.Until); err != nil {
+			return fmt.Errorf("invalid RFC 3339 timestamp %q provided for pin expiration: %w", c.Pin.Until, err)
+		}
+	}
+
+	if c.Resume != nil && c.GetJournalDir() == "" {
+		return fmt.Errorf("journal directory required to resume runs")
+	}
+
+	if c.Restore != nil {
+		if c.GetQuarantineDir() == "" {
+			return fmt.Errorf("quarantine directory required to restore files")
+		}
+		if len(c.Restore.Runs) == 0 && len(c.Restore.Files) == 0 {
+			return fmt.Errorf("one or more run IDs or files required to restore files")
+		}
+	}
+
 	// RecursiveSearch is optional
 	if c.RecursiveSearch == nil {
 		return fmt.Errorf("field RecursiveSearch not configured")
@@ -75,6 +105,19 @@ func (c Config) Validate() error {
 		return fmt.Errorf("negative number for files to keep not supported")
 	}
 
+	// Normalization is optional; filenames are compared as-is if not
+	// specified.
+	if c.Normalization != nil {
+		switch *c.Normalization {
+		case "":
+		case NormalizationNFC:
+		case NormalizationNFD:
+		case NormalizationNFKC:
+		default:
+			return fmt.Errorf("invalid option %q provided for unicode normalization form", *c.Normalization)
+		}
+	}
+
 	// We only want to work with positive file modification times 0 is
 	// acceptable as it is the default value and indicates that the user has
 	// not chosen to use the flag (or has chosen improperly and it will be
@@ -86,6 +129,25 @@ func (c Config) Validate() error {
 		return fmt.Errorf("negative number for file age not supported")
 	}
 
+	// Timezone is optional; the local time zone is used if not specified.
+	if c.Timezone != nil && *c.Timezone != "" {
+		if _, err := time.LoadLocation(*c.Timezone); err != nil {
+			return fmt.Errorf("invalid time zone %q: %w", *c.Timezone, err)
+		}
+	}
+
+	// AsOf is optional; the current time is used if not specified.
+	if c.AsOf != nil && *c.AsOf != "" {
+		if _, err := time.Parse(time.RFC3339, *c.AsOf); err != nil {
+			return fmt.Errorf("invalid RFC 3339 timestamp %q provided for as of time: %w", *c.AsOf, err)
+		}
+	}
+
+	// MaxStaleness is optional; 0 disables the check.
+	if c.MaxStaleness != nil && *c.MaxStaleness < 0 {
+		return fmt.Errorf("negative number for max staleness not supported")
+	}
+
 	if c.KeepOldest == nil {
 		return fmt.Errorf("field KeepOldest not configured")
 	}
@@ -98,6 +160,185 @@ func (c Config) Validate() error {
 		return fmt.Errorf("field IgnoreErrors not configured")
 	}
 
+	// Content matching settings are optional, but if specified must be usable.
+	if c.ContentRegex != nil && *c.ContentRegex != "" {
+		if _, err := regexp.Compile(*c.ContentRegex); err != nil {
+			return fmt.Errorf("invalid regular expression %q provided for content regex: %w", *c.ContentRegex, err)
+		}
+	}
+
+	if c.ContentBytes != nil && *c.ContentBytes <= 0 {
+		return fmt.Errorf("content bytes must be a positive number")
+	}
+
+	// ContentTypes is optional, but each entry must be in type/subtype form.
+	// The subtype may be a wildcard to match all subtypes.
+	for _, contentType := range c.ContentTypes {
+		mediaType, subType, found := strings.Cut(contentType, "/")
+		if !found || strings.TrimSpace(mediaType) == "" || strings.TrimSpace(subType) == "" {
+			return fmt.Errorf("invalid option %q provided for content type", contentType)
+		}
+	}
+
+	// SettleSeconds is optional; 0 disables the settle interval.
+	if c.SettleSeconds != nil && *c.SettleSeconds < 0 {
+		return fmt.Errorf("negative number for settle seconds not supported")
+	}
+
+	// QuarantineDays is optional; 0 disables purging of the quarantine
+	// directory.
+	if c.QuarantineDays != nil && *c.QuarantineDays < 0 {
+		return fmt.Errorf("negative number for quarantine retention not supported")
+	}
+
+	// Archive settings are optional; files are not archived unless an
+	// archive directory is specified.
+	if err := validateArchiveSettings(c.ArchiveFormat, c.ArchiveLevel); err != nil {
+		return err
+	}
+
+	// ShredPasses is optional; 0 disables shredding.
+	if err := c.validateShredPasses(c.ShredPasses); err != nil {
+		return err
+	}
+
+	for _, settings := range c.PathSettings {
+		if strings.TrimSpace(settings.Path) == "" {
+			return fmt.Errorf("path not provided for path settings")
+		}
+		if err := c.validateShredPasses(settings.ShredPasses); err != nil {
+			return fmt.Errorf("invalid settings for path %q: %w", settings.Path, err)
+		}
+		format, level := c.ArchiveFormat, c.ArchiveLevel
+		if settings.ArchiveFormat != nil {
+			format = settings.ArchiveFormat
+		}
+		if settings.ArchiveLevel != nil {
+			level = settings.ArchiveLevel
+		}
+		if err := validateArchiveSettings(format, level); err != nil {
+			return fmt.Errorf("invalid settings for path %q: %w", settings.Path, err)
+		}
+		if settings.ArchiveDir != nil && *settings.ArchiveDir != "" && c.GetCompress() != "" {
+			return fmt.Errorf(
+				"invalid settings for path %q: compressing files in place cannot be combined with an archive directory",
+				settings.Path,
+			)
+		}
+	}
+
+	// Compress is optional; files are only compressed in place if a format
+	// is specified.
+	if c.Compress != nil && *c.Compress != "" {
+		var maxLevel int
+		switch *c.Compress {
+		case CompressGzip:
+			maxLevel = 9
+		case CompressZstd:
+			maxLevel = 22
+		default:
+			return fmt.Errorf("invalid option %q provided for compress", *c.Compress)
+		}
+
+		if c.CompressLevel != nil && (*c.CompressLevel < 0 || *c.CompressLevel > maxLevel) {
+			return fmt.Errorf(
+				"invalid compression level %d provided for compress format %q (supported: 0-%d)",
+				*c.CompressLevel, *c.Compress, maxLevel,
+			)
+		}
+
+		if c.GetQuarantineDir() != "" {
+			return fmt.Errorf("compressing files in place cannot be combined with a quarantine directory")
+		}
+
+		if c.GetArchiveDir() != "" {
+			return fmt.Errorf("compressing files in place cannot be combined with an archive directory")
+		}
+	}
+
+	// Truncate settings are optional; files are only truncated if requested
+	// explicitly or if detected as open.
+	switch {
+	case c.KeepBytes != nil && *c.KeepBytes < 0:
+		return fmt.Errorf("negative number for truncate keep bytes not supported")
+	case c.KeepLines != nil && *c.KeepLines < 0:
+		return fmt.Errorf("negative number for truncate keep lines not supported")
+	case c.GetTruncateKeepBytes() > 0 && c.GetTruncateKeepLines() > 0:
+		return fmt.Errorf("truncate keep bytes and truncate keep lines cannot be combined")
+	}
+
+	if c.GetTruncate() {
+		switch {
+		case c.GetCompress() != "":
+			return fmt.Errorf("truncating files cannot be combined with compress")
+		case c.GetQuarantineDir() != "":
+			return fmt.Errorf("truncating files cannot be combined with a quarantine directory")
+		case c.GetShredPasses() > 0:
+			return fmt.Errorf("truncating files cannot be combined with shredding")
+		case len(c.Stages) > 0:
+			return fmt.Errorf("truncating files cannot be combined with lifecycle stages")
+		}
+	}
+
+	if c.Workers != nil && *c.Workers < 1 {
+		return fmt.Errorf("number of workers must be at least 1")
+	}
+
+	// Rate limits are optional; 0 disables the respective limit.
+	switch {
+	case c.DeleteRate != nil && *c.DeleteRate < 0:
+		return fmt.Errorf("negative number for delete rate not supported")
+	case c.DeleteBytesRate != nil && *c.DeleteBytesRate < 0:
+		return fmt.Errorf("negative number for delete bytes rate not supported")
+	case c.StatRate != nil && *c.StatRate < 0:
+		return fmt.Errorf("negative number for stat rate not supported")
+	}
+
+	// Only lowering the priority is supported as raising it requires
+	// elevated privileges.
+	if c.Nice != nil && (*c.Nice < 0 || *c.Nice > 19) {
+		return fmt.Errorf("invalid nice value %d provided (supported: 0-19)", *c.Nice)
+	}
+
+	// Safety limits are optional; 0 disables the respective limit.
+	switch {
+	case c.MaxRemovals != nil && *c.MaxRemovals < 0:
+		return fmt.Errorf("negative number for maximum removals not supported")
+	case c.MaxRemovalSize != nil && *c.MaxRemovalSize < 0:
+		return fmt.Errorf("negative number for maximum removal size not supported")
+	case c.MaxRemovalPct != nil && (*c.MaxRemovalPct < 0 || *c.MaxRemovalPct > 100):
+		return fmt.Errorf("invalid maximum removal percentage %d provided (supported: 0-100)", *c.MaxRemovalPct)
+	}
+
+	switch c.GetGuardScope() {
+	case GuardScopePath:
+	case GuardScopeRun:
+	default:
+		return fmt.Errorf("invalid option %q provided for guard scope", c.GetGuardScope())
+	}
+
+	// The exec command is optional, but must be a valid template if set.
+	if err := c.validateExecCommand(); err != nil {
+		return err
+	}
+
+	// Hooks are optional, but must be valid templates if set.
+	if err := c.validateHooks(); err != nil {
+		return err
+	}
+
+	// Stages are optional; matching files are removed (or handled per the
+	// other settings) if no stages are specified.
+	if err := c.validateStages(); err != nil {
+		return err
+	}
+
+	// PinAttribute is optional, but an explicitly empty attribute name cannot
+	// be used to look up pins.
+	if c.PinAttribute != nil && strings.TrimSpace(*c.PinAttribute) == "" {
+		return fmt.Errorf("empty name provided for pin attribute")
+	}
+
 	switch {
 	case c.LogFormat == nil:
 		return fmt.Errorf("field LogFormat not configured")
@@ -161,3 +402,223 @@ func (c Config) Validate() error {
 	return nil
 
 }
+
+// validateShredPasses confirms that the number of shred passes is supported
+// and that shredding is not combined with settings which keep the content of
+// files.
+func (c Config) validateShredPasses(passes *int) error {
+
+	switch {
+	case passes == nil || *passes == 0:
+		return nil
+	case *passes < 0:
+		return fmt.Errorf("negative number for shred passes not supported")
+	case c.GetCompress() != "":
+		return fmt.Errorf("shredding files cannot be combined with compress")
+	case c.GetQuarantineDir() != "":
+		return fmt.Errorf("shredding files cannot be combined with a quarantine directory")
+	}
+
+	return nil
+}
+
+// validateStages confirms that lifecycle stages are complete, that each
+// action is used at most once and that stages are not combined with other
+// settings which replace the removal action.
+func (c Config) validateStages() error {
+
+	if len(c.Stages) == 0 {
+		return nil
+	}
+
+	switch {
+	case c.GetCompress() != "":
+		return fmt.Errorf("lifecycle stages cannot be combined with compress")
+	case c.GetQuarantineDir() != "":
+		return fmt.Errorf("lifecycle stages cannot be combined with a quarantine directory")
+	case c.GetArchiveDir() != "":
+		return fmt.Errorf("lifecycle stages cannot be combined with an archive directory")
+	}
+
+	for _, settings := range c.PathSettings {
+		if settings.ArchiveDir != nil && *settings.ArchiveDir != "" {
+			return fmt.Errorf(
+				"invalid settings for path %q: lifecycle stages cannot be combined with an archive directory",
+				settings.Path,
+			)
+		}
+	}
+
+	seen := make(map[string]bool)
+
+	for _, stage := range c.Stages {
+
+		if seen[stage.Action] {
+			return fmt.Errorf("lifecycle stage action %q specified more than once", stage.Action)
+		}
+		seen[stage.Action] = true
+
+		if stage.Age < 0 {
+			return fmt.Errorf("negative age for lifecycle stage %q not supported", stage.Action)
+		}
+
+		switch stage.Action {
+		case StageActionCompress:
+			var maxLevel int
+			switch stage.Format {
+			case "", CompressGzip:
+				maxLevel = 9
+			case CompressZstd:
+				maxLevel = 22
+			default:
+				return fmt.Errorf("invalid option %q provided for lifecycle stage format", stage.Format)
+			}
+
+			if c.CompressLevel != nil && (*c.CompressLevel < 0 || *c.CompressLevel > maxLevel) {
+				return fmt.Errorf(
+					"invalid compression level %d provided for lifecycle stage format %q (supported: 0-%d)",
+					*c.CompressLevel, stage.Format, maxLevel,
+				)
+			}
+
+		case StageActionMove:
+			if strings.TrimSpace(stage.Destination) == "" {
+				return fmt.Errorf("destination required for lifecycle stage %q", stage.Action)
+			}
+
+		case StageActionDelete:
+
+		default:
+			return fmt.Errorf("invalid option %q provided for lifecycle stage action", stage.Action)
+		}
+	}
+
+	return nil
+}
+
+// validateArchiveSettings confirms that the archive format and compression
+// level are supported. Both settings are optional.
+func validateArchiveSettings(format *string, level *int) error {
+
+	archiveFormat := DefaultArchiveFormat
+	if format != nil {
+		archiveFormat = *format
+	}
+
+	var maxLevel int
+	switch archiveFormat {
+	case ArchiveFormatTarGzip:
+		maxLevel = 9
+	case ArchiveFormatTarZstd:
+		maxLevel = 22
+	default:
+		return fmt.Errorf("invalid option %q provided for archive format", archiveFormat)
+	}
+
+	if level != nil && (*level < 0 || *level > maxLevel) {
+		return fmt.Errorf(
+			"invalid compression level %d provided for archive format %q (supported: 0-%d)",
+			*level, archiveFormat, maxLevel,
+		)
+	}
+
+	return nil
+}
+
+// validateExecCommand verifies that the exec command settings are usable.
+func (c Config) validateExecCommand() error {
+
+	switch {
+	case c.ExecTimeout != nil && *c.ExecTimeout < 0:
+		return fmt.Errorf("negative number for exec timeout not supported")
+	case c.ExecConcurrency != nil && *c.ExecConcurrency < 1:
+		return fmt.Errorf("exec concurrency must be at least 1")
+	}
+
+	placeholders := map[string]interface{}{
+		"Path":    "",
+		"Name":    "",
+		"Dir":     "",
+		"Size":    int64(0),
+		"ModTime": "",
+		"RunID":   "",
+	}
+
+	return validateCommandTemplate("exec command", c.ExecCommand, placeholders)
+}
+
+// validateHooks verifies that the pre and post hook settings are usable.
+func (c Config) validateHooks() error {
+
+	if c.HookTimeout != nil && *c.HookTimeout < 0 {
+		return fmt.Errorf("negative number for hook timeout not supported")
+	}
+
+	placeholders := map[string]interface{}{
+		"Path":  "",
+		"RunID": "",
+	}
+
+	if err := validateCommandTemplate("pre hook", c.PreHook, placeholders); err != nil {
+		return err
+	}
+
+	return validateCommandTemplate("post hook", c.PostHook, placeholders)
+}
+
+// validateProtectedPaths verifies that no path to process resolves to a
+// protected system location. Paths are canonicalized first so that the check
+// cannot be bypassed via symlinks or ".." elements. The check may only be
+// disabled via configuration file.
+func (c Config) validateProtectedPaths() error {
+
+	if c.GetAllowProtectedPaths() {
+		return nil
+	}
+
+	for _, path := range c.Paths {
+		canonicalPath, err := canonicalSearchPath(path)
+		if err != nil {
+			return fmt.Errorf("unable to resolve path %q: %w", path, err)
+		}
+
+		if location, protected := protectedLocation(canonicalPath); protected {
+			return fmt.Errorf(
+				"path %q (resolved to %q) is protected system location %q; set allow_protected_paths in the configuration file to process it",
+				path,
+				canonicalPath,
+				location,
+			)
+		}
+	}
+
+	return nil
+}
+
+// validateCommandTemplate verifies that each argument of an optional command
+// is a valid template. Arguments are expanded with the provided placeholder
+// values so that references to unknown fields are caught before any file is
+// processed.
+func validateCommandTemplate(name string, command *string, placeholders map[string]interface{}) error {
+
+	if command == nil {
+		return nil
+	}
+
+	if *command != "" && strings.TrimSpace(*command) == "" {
+		return fmt.Errorf("empty %s provided", name)
+	}
+
+	for _, field := range strings.Fields(*command) {
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(field)
+		if err != nil {
+			return fmt.Errorf("invalid %s argument %q: %w", name, field, err)
+		}
+
+		if err := tmpl.Execute(io.Discard, placeholders); err != nil {
+			return fmt.Errorf("invalid %s argument %q: %w", name, field, err)
+		}
+	}
+
+	return nil
+}
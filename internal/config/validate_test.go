@@ -17,6 +17,9 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/atc0005/elbow/internal/logging"
@@ -605,4 +608,761 @@ func TestValidate(t *testing.T) {
 		}
 	})
 
+	t.Run("ContentRegex set to invalid value", func(t *testing.T) {
+		tmpContentRegex := *c.ContentRegex
+		*c.ContentRegex = "COMPLETE("
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid value %q for ContentRegex: %s", *c.ContentRegex, err)
+		} else {
+			t.Logf("Config failed as expected after setting ContentRegex to %q: %s", *c.ContentRegex, err)
+		}
+		// Set back to prior value
+		*c.ContentRegex = tmpContentRegex
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring ContentRegex: %s", err)
+		} else {
+			t.Log("Validation successful after restoring ContentRegex field")
+		}
+	})
+
+	t.Run("ContentBytes set to invalid value", func(t *testing.T) {
+		tmpContentBytes := *c.ContentBytes
+		*c.ContentBytes = 0
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid value %d for ContentBytes: %s", *c.ContentBytes, err)
+		} else {
+			t.Logf("Config failed as expected after setting ContentBytes to %d: %s", *c.ContentBytes, err)
+		}
+		// Set back to prior value
+		*c.ContentBytes = tmpContentBytes
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring ContentBytes: %s", err)
+		} else {
+			t.Log("Validation successful after restoring ContentBytes field")
+		}
+	})
+
+	t.Run("ContentTypes set to invalid value", func(t *testing.T) {
+		tmpContentTypes := c.ContentTypes
+		c.ContentTypes = []string{"application/zip", "zip"}
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid value %q for ContentTypes: %s", c.ContentTypes, err)
+		} else {
+			t.Logf("Config failed as expected after setting ContentTypes to %q: %s", c.ContentTypes, err)
+		}
+		// Set back to prior value
+		c.ContentTypes = tmpContentTypes
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring ContentTypes: %s", err)
+		} else {
+			t.Log("Validation successful after restoring ContentTypes field")
+		}
+	})
+
+	t.Run("Paths set to nil with pin subcommand", func(t *testing.T) {
+		tmpPaths := c.Paths
+		c.Paths = nil
+		c.Pin = &PinCmd{Files: []string{"/tmp/elbow/path1/keep.war"}}
+		if err := c.Validate(); err != nil {
+			t.Errorf("Config failed, but should have passed on nil Paths with pin subcommand: %v", err)
+		} else {
+			t.Log("Config passed as expected after setting Paths to nil with pin subcommand")
+		}
+
+		c.Pin.Until = "tomorrow"
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid value %q for Pin.Until: %s", c.Pin.Until, err)
+		} else {
+			t.Logf("Config failed as expected after setting Pin.Until to %q: %s", c.Pin.Until, err)
+		}
+
+		// Set back to prior value
+		c.Pin = nil
+		c.Paths = tmpPaths
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring Paths: %s", err)
+		} else {
+			t.Log("Validation successful after restoring Paths field")
+		}
+	})
+
+	t.Run("MaxStaleness set to invalid value", func(t *testing.T) {
+		tmpMaxStaleness := *c.MaxStaleness
+		*c.MaxStaleness = -1
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid value %d for MaxStaleness: %s", *c.MaxStaleness, err)
+		} else {
+			t.Logf("Config failed as expected after setting MaxStaleness to %d: %s", *c.MaxStaleness, err)
+		}
+		// Set back to prior value
+		*c.MaxStaleness = tmpMaxStaleness
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring MaxStaleness: %s", err)
+		} else {
+			t.Log("Validation successful after restoring MaxStaleness field")
+		}
+	})
+
+	t.Run("SettleSeconds set to invalid value", func(t *testing.T) {
+		tmpSettleSeconds := *c.SettleSeconds
+		*c.SettleSeconds = -1
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid value %d for SettleSeconds: %s", *c.SettleSeconds, err)
+		} else {
+			t.Logf("Config failed as expected after setting SettleSeconds to %d: %s", *c.SettleSeconds, err)
+		}
+		// Set back to prior value
+		*c.SettleSeconds = tmpSettleSeconds
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring SettleSeconds: %s", err)
+		} else {
+			t.Log("Validation successful after restoring SettleSeconds field")
+		}
+	})
+
+	t.Run("Timezone set to invalid value", func(t *testing.T) {
+		tmpTimezone := *c.Timezone
+		*c.Timezone = "Invalid/Zone"
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid value %q for Timezone: %s", *c.Timezone, err)
+		} else {
+			t.Logf("Config failed as expected after setting Timezone to %q: %s", *c.Timezone, err)
+		}
+		// Set back to prior value
+		*c.Timezone = tmpTimezone
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring Timezone: %s", err)
+		} else {
+			t.Log("Validation successful after restoring Timezone field")
+		}
+	})
+
+	t.Run("AsOf set to invalid value", func(t *testing.T) {
+		tmpAsOf := *c.AsOf
+		*c.AsOf = "next monday"
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid value %q for AsOf: %s", *c.AsOf, err)
+		} else {
+			t.Logf("Config failed as expected after setting AsOf to %q: %s", *c.AsOf, err)
+		}
+		// Set back to prior value
+		*c.AsOf = tmpAsOf
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring AsOf: %s", err)
+		} else {
+			t.Log("Validation successful after restoring AsOf field")
+		}
+	})
+
+	t.Run("Normalization set to invalid value", func(t *testing.T) {
+		tmpNormalization := *c.Normalization
+		*c.Normalization = "nfkd"
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid value %q for Normalization: %s", *c.Normalization, err)
+		} else {
+			t.Logf("Config failed as expected after setting Normalization to %q: %s", *c.Normalization, err)
+		}
+		// Set back to prior value
+		*c.Normalization = tmpNormalization
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring Normalization: %s", err)
+		} else {
+			t.Log("Validation successful after restoring Normalization field")
+		}
+	})
+
+	t.Run("QuarantineDays set to invalid value", func(t *testing.T) {
+		tmpQuarantineDays := *c.QuarantineDays
+		*c.QuarantineDays = -1
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid value %d for QuarantineDays: %s", *c.QuarantineDays, err)
+		} else {
+			t.Logf("Config failed as expected after setting QuarantineDays to %d: %s", *c.QuarantineDays, err)
+		}
+		// Set back to prior value
+		*c.QuarantineDays = tmpQuarantineDays
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring QuarantineDays: %s", err)
+		} else {
+			t.Log("Validation successful after restoring QuarantineDays field")
+		}
+	})
+
+	t.Run("Restore subcommand without quarantine directory", func(t *testing.T) {
+		tmpQuarantineDir := *c.QuarantineDir
+		*c.QuarantineDir = ""
+		c.Restore = &RestoreCmd{Runs: []string{"20260102T150405Z-1a2b3c4d"}}
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on restore subcommand without QuarantineDir: %s", err)
+		} else {
+			t.Logf("Config failed as expected for restore subcommand without QuarantineDir: %s", err)
+		}
+
+		*c.QuarantineDir = "/tmp/elbow/quarantine"
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for restore subcommand with QuarantineDir: %s", err)
+		}
+
+		c.Restore.Runs = nil
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on restore subcommand without runs or files: %s", err)
+		} else {
+			t.Logf("Config failed as expected for restore subcommand without runs or files: %s", err)
+		}
+
+		// Set back to prior value
+		c.Restore = nil
+		*c.QuarantineDir = tmpQuarantineDir
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring QuarantineDir: %s", err)
+		} else {
+			t.Log("Validation successful after restoring QuarantineDir field")
+		}
+	})
+
+	t.Run("Resume subcommand without journal directory", func(t *testing.T) {
+		tmpJournalDir := *c.JournalDir
+		*c.JournalDir = ""
+		c.Resume = &ResumeCmd{}
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on resume subcommand without JournalDir: %s", err)
+		} else {
+			t.Logf("Config failed as expected for resume subcommand without JournalDir: %s", err)
+		}
+
+		*c.JournalDir = "/tmp/elbow/journal"
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for resume subcommand with JournalDir: %s", err)
+		}
+
+		// Set back to prior value
+		c.Resume = nil
+		*c.JournalDir = tmpJournalDir
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring JournalDir: %s", err)
+		} else {
+			t.Log("Validation successful after restoring JournalDir field")
+		}
+	})
+
+	t.Run("ArchiveFormat set to invalid value", func(t *testing.T) {
+		tmpArchiveFormat := *c.ArchiveFormat
+		*c.ArchiveFormat = "zip"
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid value %q for ArchiveFormat: %s", *c.ArchiveFormat, err)
+		} else {
+			t.Logf("Config failed as expected after setting ArchiveFormat to %q: %s", *c.ArchiveFormat, err)
+		}
+		// Set back to prior value
+		*c.ArchiveFormat = tmpArchiveFormat
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring ArchiveFormat: %s", err)
+		} else {
+			t.Log("Validation successful after restoring ArchiveFormat field")
+		}
+	})
+
+	t.Run("ArchiveLevel set to invalid value", func(t *testing.T) {
+		tmpArchiveLevel := *c.ArchiveLevel
+		*c.ArchiveLevel = 19
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid value %d for ArchiveLevel: %s", *c.ArchiveLevel, err)
+		} else {
+			t.Logf("Config failed as expected after setting ArchiveLevel to %d: %s", *c.ArchiveLevel, err)
+		}
+		// Set back to prior value
+		*c.ArchiveLevel = tmpArchiveLevel
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring ArchiveLevel: %s", err)
+		} else {
+			t.Log("Validation successful after restoring ArchiveLevel field")
+		}
+	})
+
+	t.Run("PathSettings set to invalid value", func(t *testing.T) {
+		zstdFormat := ArchiveFormatTarZstd
+		zstdLevel := 19
+		c.PathSettings = []PathSettings{
+			{Path: "/tmp/elbow/path1", ArchiveFormat: &zstdFormat, ArchiveLevel: &zstdLevel},
+		}
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for valid PathSettings: %s", err)
+		}
+
+		c.PathSettings[0].ArchiveFormat = nil
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on PathSettings level %d for default archive format: %s", zstdLevel, err)
+		} else {
+			t.Logf("Config failed as expected for PathSettings level %d with default archive format: %s", zstdLevel, err)
+		}
+
+		c.PathSettings[0] = PathSettings{}
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on PathSettings without path: %s", err)
+		} else {
+			t.Logf("Config failed as expected for PathSettings without path: %s", err)
+		}
+
+		// Set back to prior value
+		c.PathSettings = nil
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring PathSettings: %s", err)
+		} else {
+			t.Log("Validation successful after restoring PathSettings field")
+		}
+	})
+
+	t.Run("Compress set to invalid value", func(t *testing.T) {
+		tmpCompress := c.Compress
+		tmpCompressLevel := c.CompressLevel
+
+		invalidCompress := "bzip2"
+		c.Compress = &invalidCompress
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on invalid Compress value: %s", err)
+		} else {
+			t.Logf("Config failed as expected for invalid Compress value %q: %s", invalidCompress, err)
+		}
+
+		gzipCompress := CompressGzip
+		invalidLevel := 19
+		c.Compress = &gzipCompress
+		c.CompressLevel = &invalidLevel
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on CompressLevel %d for gzip: %s", invalidLevel, err)
+		} else {
+			t.Logf("Config failed as expected for CompressLevel %d with gzip: %s", invalidLevel, err)
+		}
+
+		zstdCompress := CompressZstd
+		c.Compress = &zstdCompress
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for valid CompressLevel %d with zstd: %s", invalidLevel, err)
+		}
+
+		tmpQuarantineDir := c.QuarantineDir
+		quarantineDir := "/tmp/elbow/quarantine"
+		c.QuarantineDir = &quarantineDir
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on Compress combined with QuarantineDir: %s", err)
+		} else {
+			t.Logf("Config failed as expected for Compress combined with QuarantineDir: %s", err)
+		}
+		c.QuarantineDir = tmpQuarantineDir
+
+		// Set back to prior values
+		c.Compress = tmpCompress
+		c.CompressLevel = tmpCompressLevel
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring Compress: %s", err)
+		} else {
+			t.Log("Validation successful after restoring Compress field")
+		}
+	})
+
+	t.Run("Stages set to invalid value", func(t *testing.T) {
+		c.Stages = []Stage{
+			{Action: StageActionCompress, Age: 7, Format: CompressZstd},
+			{Action: StageActionMove, Age: 30, Destination: "/tmp/elbow/archive"},
+			{Action: StageActionDelete, Age: 365},
+		}
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for valid Stages: %s", err)
+		}
+
+		invalidStages := map[string][]Stage{
+			"unknown action":      {{Action: "shred", Age: 1}},
+			"duplicate action":    {{Action: StageActionDelete, Age: 1}, {Action: StageActionDelete, Age: 2}},
+			"negative age":        {{Action: StageActionDelete, Age: -1}},
+			"move without target": {{Action: StageActionMove, Age: 30}},
+			"unknown format":      {{Action: StageActionCompress, Age: 7, Format: "bzip2"}},
+		}
+
+		for desc, stages := range invalidStages {
+			c.Stages = stages
+			if err := c.Validate(); err == nil {
+				t.Errorf("Config passed, but should have failed on Stages with %s: %s", desc, err)
+			} else {
+				t.Logf("Config failed as expected for Stages with %s: %s", desc, err)
+			}
+		}
+
+		tmpCompress := c.Compress
+		gzipCompress := CompressGzip
+		c.Compress = &gzipCompress
+		c.Stages = []Stage{{Action: StageActionDelete, Age: 365}}
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on Stages combined with Compress: %s", err)
+		} else {
+			t.Logf("Config failed as expected for Stages combined with Compress: %s", err)
+		}
+		c.Compress = tmpCompress
+
+		// Set back to prior value
+		c.Stages = nil
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring Stages: %s", err)
+		} else {
+			t.Log("Validation successful after restoring Stages field")
+		}
+	})
+
+	t.Run("ShredPasses set to invalid value", func(t *testing.T) {
+		tmpShredPasses := c.ShredPasses
+
+		invalidPasses := -1
+		c.ShredPasses = &invalidPasses
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on ShredPasses %d: %s", invalidPasses, err)
+		} else {
+			t.Logf("Config failed as expected for ShredPasses %d: %s", invalidPasses, err)
+		}
+
+		validPasses := 3
+		c.ShredPasses = &validPasses
+		tmpQuarantineDir := c.QuarantineDir
+		quarantineDir := "/tmp/elbow/quarantine"
+		c.QuarantineDir = &quarantineDir
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on ShredPasses combined with QuarantineDir: %s", err)
+		} else {
+			t.Logf("Config failed as expected for ShredPasses combined with QuarantineDir: %s", err)
+		}
+		c.QuarantineDir = tmpQuarantineDir
+
+		c.PathSettings = []PathSettings{{Path: "/tmp/elbow/path1", ShredPasses: &invalidPasses}}
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on PathSettings ShredPasses %d: %s", invalidPasses, err)
+		} else {
+			t.Logf("Config failed as expected for PathSettings ShredPasses %d: %s", invalidPasses, err)
+		}
+		c.PathSettings = nil
+
+		// Set back to prior value
+		c.ShredPasses = tmpShredPasses
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring ShredPasses: %s", err)
+		} else {
+			t.Log("Validation successful after restoring ShredPasses field")
+		}
+	})
+
+	t.Run("Truncate settings set to invalid value", func(t *testing.T) {
+		tmpKeepBytes := c.KeepBytes
+		tmpKeepLines := c.KeepLines
+		tmpTruncate := c.Truncate
+
+		invalidKeep := -1
+		c.KeepBytes = &invalidKeep
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on KeepBytes %d: %s", invalidKeep, err)
+		} else {
+			t.Logf("Config failed as expected for KeepBytes %d: %s", invalidKeep, err)
+		}
+
+		keepBytes := 1024
+		keepLines := 100
+		c.KeepBytes = &keepBytes
+		c.KeepLines = &keepLines
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on KeepBytes combined with KeepLines: %s", err)
+		} else {
+			t.Logf("Config failed as expected for KeepBytes combined with KeepLines: %s", err)
+		}
+		c.KeepBytes = tmpKeepBytes
+
+		truncate := true
+		c.Truncate = &truncate
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for valid Truncate settings: %s", err)
+		}
+
+		c.Stages = []Stage{{Action: StageActionDelete, Age: 365}}
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on Truncate combined with Stages: %s", err)
+		} else {
+			t.Logf("Config failed as expected for Truncate combined with Stages: %s", err)
+		}
+		c.Stages = nil
+
+		// Set back to prior values
+		c.KeepLines = tmpKeepLines
+		c.Truncate = tmpTruncate
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring Truncate settings: %s", err)
+		} else {
+			t.Log("Validation successful after restoring Truncate settings")
+		}
+	})
+
+	t.Run("Exec settings", func(t *testing.T) {
+
+		tmpExecCommand := c.ExecCommand
+		tmpExecTimeout := c.ExecTimeout
+		tmpExecConcurrency := c.ExecConcurrency
+
+		validCommand := "catalog-tool deregister --size {{.Size}} {{.Path}}"
+		c.ExecCommand = &validCommand
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for valid ExecCommand %q: %s", validCommand, err)
+		}
+
+		for _, invalidCommand := range []string{
+			"catalog-tool deregister {{.Path}",
+			"catalog-tool deregister {{.Owner}}",
+			"   ",
+		} {
+			invalidCommand := invalidCommand
+			c.ExecCommand = &invalidCommand
+			if err := c.Validate(); err == nil {
+				t.Errorf("Config passed, but should have failed on ExecCommand %q: %s", invalidCommand, err)
+			} else {
+				t.Logf("Config failed as expected for ExecCommand %q: %s", invalidCommand, err)
+			}
+		}
+		c.ExecCommand = &validCommand
+
+		invalidTimeout := -1
+		c.ExecTimeout = &invalidTimeout
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on ExecTimeout %d: %s", invalidTimeout, err)
+		} else {
+			t.Logf("Config failed as expected for ExecTimeout %d: %s", invalidTimeout, err)
+		}
+		c.ExecTimeout = tmpExecTimeout
+
+		invalidConcurrency := 0
+		c.ExecConcurrency = &invalidConcurrency
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on ExecConcurrency %d: %s", invalidConcurrency, err)
+		} else {
+			t.Logf("Config failed as expected for ExecConcurrency %d: %s", invalidConcurrency, err)
+		}
+
+		// Set back to prior values
+		c.ExecCommand = tmpExecCommand
+		c.ExecConcurrency = tmpExecConcurrency
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring Exec settings: %s", err)
+		} else {
+			t.Log("Validation successful after restoring Exec settings")
+		}
+	})
+
+	t.Run("Hook settings", func(t *testing.T) {
+
+		tmpPreHook := c.PreHook
+		tmpPostHook := c.PostHook
+		tmpHookTimeout := c.HookTimeout
+
+		validHook := "snapshot --run={{.RunID}} {{.Path}}"
+		c.PreHook = &validHook
+		c.PostHook = &validHook
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for valid hook %q: %s", validHook, err)
+		}
+
+		invalidHook := "snapshot {{.Size}}"
+		c.PostHook = &invalidHook
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on PostHook %q: %s", invalidHook, err)
+		} else {
+			t.Logf("Config failed as expected for PostHook %q: %s", invalidHook, err)
+		}
+		c.PostHook = tmpPostHook
+
+		invalidTimeout := -1
+		c.HookTimeout = &invalidTimeout
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on HookTimeout %d: %s", invalidTimeout, err)
+		} else {
+			t.Logf("Config failed as expected for HookTimeout %d: %s", invalidTimeout, err)
+		}
+
+		// Set back to prior values
+		c.PreHook = tmpPreHook
+		c.HookTimeout = tmpHookTimeout
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring Hook settings: %s", err)
+		} else {
+			t.Log("Validation successful after restoring Hook settings")
+		}
+	})
+
+	t.Run("Workers", func(t *testing.T) {
+
+		tmpWorkers := c.Workers
+
+		invalidWorkers := 0
+		c.Workers = &invalidWorkers
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on Workers %d: %s", invalidWorkers, err)
+		} else {
+			t.Logf("Config failed as expected for Workers %d: %s", invalidWorkers, err)
+		}
+
+		// Set back to prior value
+		c.Workers = tmpWorkers
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring Workers: %s", err)
+		} else {
+			t.Log("Validation successful after restoring Workers")
+		}
+	})
+
+	t.Run("Throttle settings", func(t *testing.T) {
+
+		tmpDeleteRate := c.DeleteRate
+		tmpStatRate := c.StatRate
+		tmpNice := c.Nice
+
+		invalidRate := -1
+		c.DeleteRate = &invalidRate
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on DeleteRate %d: %s", invalidRate, err)
+		} else {
+			t.Logf("Config failed as expected for DeleteRate %d: %s", invalidRate, err)
+		}
+		c.DeleteRate = tmpDeleteRate
+
+		c.StatRate = &invalidRate
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on StatRate %d: %s", invalidRate, err)
+		} else {
+			t.Logf("Config failed as expected for StatRate %d: %s", invalidRate, err)
+		}
+		c.StatRate = tmpStatRate
+
+		for _, invalidNice := range []int{-5, 20} {
+			invalidNice := invalidNice
+			c.Nice = &invalidNice
+			if err := c.Validate(); err == nil {
+				t.Errorf("Config passed, but should have failed on Nice %d: %s", invalidNice, err)
+			} else {
+				t.Logf("Config failed as expected for Nice %d: %s", invalidNice, err)
+			}
+		}
+
+		// Set back to prior value
+		c.Nice = tmpNice
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring Throttle settings: %s", err)
+		} else {
+			t.Log("Validation successful after restoring Throttle settings")
+		}
+	})
+
+	t.Run("Guard settings", func(t *testing.T) {
+
+		tmpMaxRemovals := c.MaxRemovals
+		tmpMaxRemovalPct := c.MaxRemovalPct
+		tmpGuardScope := c.GuardScope
+
+		invalidMaxRemovals := -1
+		c.MaxRemovals = &invalidMaxRemovals
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on MaxRemovals %d: %s", invalidMaxRemovals, err)
+		} else {
+			t.Logf("Config failed as expected for MaxRemovals %d: %s", invalidMaxRemovals, err)
+		}
+		c.MaxRemovals = tmpMaxRemovals
+
+		invalidPct := 101
+		c.MaxRemovalPct = &invalidPct
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on MaxRemovalPct %d: %s", invalidPct, err)
+		} else {
+			t.Logf("Config failed as expected for MaxRemovalPct %d: %s", invalidPct, err)
+		}
+		c.MaxRemovalPct = tmpMaxRemovalPct
+
+		invalidScope := "everything"
+		c.GuardScope = &invalidScope
+		if err := c.Validate(); err == nil {
+			t.Errorf("Config passed, but should have failed on GuardScope %q: %s", invalidScope, err)
+		} else {
+			t.Logf("Config failed as expected for GuardScope %q: %s", invalidScope, err)
+		}
+
+		// Set back to prior value
+		c.GuardScope = tmpGuardScope
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring Guard settings: %s", err)
+		} else {
+			t.Log("Validation successful after restoring Guard settings")
+		}
+	})
+
+	t.Run("Protected paths", func(t *testing.T) {
+
+		if runtime.GOOS == "windows" {
+			t.Skip("protected locations tested here are specific to Unix-like systems")
+		}
+
+		tmpPaths := c.Paths
+		tmpAllowProtectedPaths := c.AllowProtectedPaths
+
+		link := filepath.Join(t.TempDir(), "config")
+		if err := os.Symlink("/etc", link); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, path := range []string{"/", "/etc", "/etc/ssl", "/tmp/..", "/tmp/../usr/lib", link, link + "/.."} {
+			c.Paths = []string{"/tmp/elbow/path1", path}
+			if err := c.Validate(); err == nil {
+				t.Errorf("Config passed, but should have failed on protected path %q: %s", path, err)
+			} else {
+				t.Logf("Config failed as expected for protected path %q: %s", path, err)
+			}
+		}
+
+		for _, path := range []string{"/var/log/app", "/home/app/logs", filepath.Dir(link)} {
+			c.Paths = []string{path}
+			if err := c.Validate(); err != nil {
+				t.Errorf("Validation failed for unprotected path %q: %s", path, err)
+			}
+		}
+
+		allow := true
+		c.AllowProtectedPaths = &allow
+		c.Paths = []string{"/etc"}
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for protected path with AllowProtectedPaths set: %s", err)
+		}
+
+		// Set back to prior value
+		c.Paths = tmpPaths
+		c.AllowProtectedPaths = tmpAllowProtectedPaths
+
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validation failed for config after restoring Paths: %s", err)
+		} else {
+			t.Log("Validation successful after restoring Paths")
+		}
+	})
+
 }
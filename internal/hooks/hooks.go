@@ -0,0 +1,286 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hooks runs user-provided commands before and after pruning each
+// path, e.g., to stop a service or take a snapshot before files are removed.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/atc0005/elbow/internal/actions"
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/paths"
+	"github.com/sirupsen/logrus"
+)
+
+// Names of the supported hooks.
+const (
+	Pre  string = "pre"
+	Post string = "post"
+)
+
+// waitDelay is the time allowed for the output of a hook to be collected
+// once the hook exited or timed out. Processes started by the hook (e.g., a
+// daemon) may keep its output open indefinitely.
+const waitDelay = 2 * time.Second
+
+// Data is the data available to hook command templates.
+type Data struct {
+	Path  string
+	RunID string
+}
+
+// Report is provided as JSON on standard input to the post hook and
+// summarizes the results of pruning a path.
+type Report struct {
+	Path        string       `json:"path"`
+	RunID       string       `json:"run_id"`
+	Removed     int          `json:"removed"`
+	RemovedSize int64        `json:"removed_size"`
+	Modified    int          `json:"modified"`
+	SavedSize   int64        `json:"saved_size"`
+	Skipped     int          `json:"skipped"`
+	SkippedSize int64        `json:"skipped_size"`
+	Failed      int          `json:"failed"`
+	FailedSize  int64        `json:"failed_size"`
+	Files       []ReportFile `json:"files"`
+}
+
+// ReportFile is the outcome of the action applied to a single file, as
+// included in a Report.
+type ReportFile struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	Action      string `json:"action"`
+	Status      string `json:"status"`
+	Removed     bool   `json:"removed"`
+	Saved       int64  `json:"saved,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	SkipReason  string `json:"skip_reason,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// NewReport returns a summary of the results of pruning the path.
+func NewReport(path string, runID string, results paths.PathPruningResults) Report {
+
+	report := Report{
+		Path:        path,
+		RunID:       runID,
+		Removed:     len(results.SuccessfulRemovals),
+		RemovedSize: results.SuccessfulRemovals.TotalFileSize(),
+		Modified:    len(results.Modified),
+		SavedSize:   results.SavedFileSize(),
+		Skipped:     len(results.Skipped),
+		SkippedSize: results.Skipped.TotalFileSize(),
+		Failed:      len(results.FailedRemovals),
+		FailedSize:  results.FailedRemovals.TotalFileSize(),
+		Files:       make([]ReportFile, 0, len(results.Outcomes)),
+	}
+
+	for _, outcome := range results.Outcomes {
+		file := ReportFile{
+			Path:        outcome.File.Path,
+			Action:      outcome.Action,
+			Status:      outcome.Status.String(),
+			Removed:     outcome.Removed,
+			Destination: outcome.Destination,
+			SkipReason:  outcome.SkipReason,
+		}
+
+		if outcome.File.FileInfo != nil {
+			file.Size = outcome.File.Size()
+		}
+
+		if outcome.Status == actions.Succeeded {
+			file.Saved = outcome.Saved
+		}
+
+		if outcome.Err != nil {
+			file.Error = outcome.Err.Error()
+		}
+
+		report.Files = append(report.Files, file)
+	}
+
+	return report
+}
+
+// RunPre runs the pre hook for the path. An error is returned if the hook
+// exits with a non-zero status, in which case the path should not be
+// pruned.
+func RunPre(c *config.Config, path string) error {
+	return run(c, Pre, c.GetPreHook(), path, nil)
+}
+
+// RunPost runs the post hook for the path, providing the results of pruning
+// the path as JSON on standard input.
+func RunPost(c *config.Config, path string, results paths.PathPruningResults) error {
+
+	report, err := json.Marshal(NewReport(path, c.GetRunID(), results))
+	if err != nil {
+		return fmt.Errorf("failed to encode results for post hook: %w", err)
+	}
+
+	return run(c, Post, c.GetPostHook(), path, report)
+}
+
+// run runs a hook command. Output of the command is logged line by line as
+// it is produced: standard output at info level and standard error at
+// warning level.
+func run(c *config.Config, hook string, command string, path string, input []byte) error {
+
+	log := c.GetLogger()
+
+	argv, err := Args(command, Data{Path: path, RunID: c.GetRunID()})
+	if err != nil {
+		return err
+	}
+
+	if len(argv) == 0 {
+		return fmt.Errorf("empty %s hook command", hook)
+	}
+
+	ctx := context.Background()
+	if timeout := c.GetHookTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	// The command is provided by the user running this application.
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(),
+		"ELBOW_HOOK="+hook,
+		"ELBOW_PATH="+path,
+		"ELBOW_RUN_ID="+c.GetRunID(),
+	)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.WaitDelay = waitDelay
+
+	entry := log.WithFields(logrus.Fields{
+		"hook": hook,
+		"path": path,
+	})
+
+	stdout := &lineLogger{entry: entry, level: logrus.InfoLevel}
+	stderr := &lineLogger{entry: entry, level: logrus.WarnLevel}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	entry.WithFields(logrus.Fields{
+		"command": argv,
+	}).Infof("Running %s hook", hook)
+
+	err = cmd.Run()
+
+	stdout.Flush()
+	stderr.Flush()
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%s hook %q timed out after %ds", hook, argv[0], c.GetHookTimeout())
+	case errors.Is(err, exec.ErrWaitDelay):
+		entry.Warnf("The %s hook exited, but left its output open", hook)
+	case err != nil:
+		return fmt.Errorf("%s hook %q failed: %w", hook, argv[0], err)
+	}
+
+	return nil
+}
+
+// Args splits the command on whitespace and expands each argument as a
+// template using the provided data.
+func Args(command string, data Data) ([]string, error) {
+
+	fields := strings.Fields(command)
+	argv := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		tmpl, err := template.New("hook").Option("missingkey=error").Parse(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hook command argument %q: %w", field, err)
+		}
+
+		var arg strings.Builder
+		if err := tmpl.Execute(&arg, data); err != nil {
+			return nil, fmt.Errorf("invalid hook command argument %q: %w", field, err)
+		}
+
+		argv = append(argv, arg.String())
+	}
+
+	return argv, nil
+}
+
+// lineLogger is an io.Writer which logs each complete line written to it.
+type lineLogger struct {
+	mu      sync.Mutex
+	entry   *logrus.Entry
+	level   logrus.Level
+	partial []byte
+}
+
+// Write logs each complete line in p, keeping any incomplete line until the
+// rest of it is written or Flush is called.
+func (l *lineLogger) Write(p []byte) (int, error) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.partial = append(l.partial, p...)
+
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+		l.log(l.partial[:i])
+		l.partial = l.partial[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush logs any incomplete line.
+func (l *lineLogger) Flush() {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.partial) > 0 {
+		l.log(l.partial)
+		l.partial = nil
+	}
+}
+
+// log logs a single line of output, ignoring blank lines.
+func (l *lineLogger) log(line []byte) {
+	if text := strings.TrimRight(string(line), "\r"); strings.TrimSpace(text) != "" {
+		l.entry.Log(l.level, text)
+	}
+}
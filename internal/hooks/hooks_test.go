@@ -0,0 +1,196 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/elbow/internal/actions"
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/paths"
+)
+
+func TestArgs(t *testing.T) {
+
+	data := Data{Path: "/var/log/app", RunID: "run-1"}
+
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{
+			command: "systemctl stop app",
+			want:    []string{"systemctl", "stop", "app"},
+		},
+		{
+			command: "snapshot --run={{.RunID}} {{.Path}}",
+			want:    []string{"snapshot", "--run=run-1", "/var/log/app"},
+		},
+		{
+			command: "snapshot {{.Size}}",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got, err := Args(tt.command, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %t", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewReport(t *testing.T) {
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(path, []byte("hooks test\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := matches.FileMatch{FileInfo: info, Path: path}
+
+	var results paths.PathPruningResults
+	results.Add(paths.PathPruningResults{
+		SuccessfulRemovals: matches.FileMatches{file},
+		Outcomes: []actions.Outcome{
+			{Action: actions.NameDelete, File: file, Status: actions.Succeeded, Removed: true},
+			{Action: actions.NameDelete, File: file, Status: actions.Failed, Err: errors.New("permission denied")},
+		},
+	})
+
+	report := NewReport(dir, "run-1", results)
+
+	if report.Removed != 1 || report.RemovedSize != info.Size() || report.Failed != 1 || len(report.Files) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	if report.Files[1].Status != actions.Failed.String() || report.Files[1].Error != "permission denied" {
+		t.Errorf("unexpected report for failed file: %+v", report.Files[1])
+	}
+}
+
+func TestRun(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests rely on a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	reportFile := filepath.Join(dir, "report.json")
+
+	scripts := map[string]string{
+		"veto.sh":   "echo \"stopping service for $ELBOW_PATH\"\necho 'service busy' >&2\nexit 1\n",
+		"report.sh": "echo \"$ELBOW_HOOK hook\"\ncat > " + reportFile + "\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := config.NewDefaultConfig()
+	var output bytes.Buffer
+	c.GetLogger().SetOutput(&output)
+
+	*c.PreHook = "sh " + filepath.Join(dir, "veto.sh")
+	if err := RunPre(&c, dir); err == nil {
+		t.Errorf("pre hook passed, but should have vetoed pruning the path")
+	}
+
+	for _, want := range []string{"stopping service for " + dir, "service busy"} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("hook output %q not logged: %s", want, output.String())
+		}
+	}
+
+	c.GetLogger().SetOutput(io.Discard)
+
+	*c.PostHook = "sh " + filepath.Join(dir, "report.sh")
+	if err := RunPost(&c, dir, paths.PathPruningResults{}); err != nil {
+		t.Fatalf("post hook failed: %s", err)
+	}
+
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("post hook did not receive valid JSON: %s", err)
+	}
+
+	if report.Path != dir || report.RunID != c.GetRunID() {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestRunTimeoutBackgroundChild(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests rely on a POSIX shell")
+	}
+
+	// The backgrounded sleep inherits the output of the hook and outlives
+	// it when the hook is killed on timeout.
+	script := filepath.Join(t.TempDir(), "daemon.sh")
+	if err := os.WriteFile(script, []byte("sleep 30 &\nsleep 30\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := config.NewDefaultConfig()
+	c.GetLogger().SetOutput(io.Discard)
+
+	*c.PreHook = "sh " + script
+	*c.HookTimeout = 1
+
+	start := time.Now()
+	err := RunPre(&c, filepath.Dir(script))
+	elapsed := time.Since(start)
+
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+
+	if elapsed > 10*time.Second {
+		t.Errorf("hook returned after %s; waited on background child", elapsed)
+	}
+}
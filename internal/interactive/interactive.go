@@ -0,0 +1,331 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package interactive lets an operator review the files planned for removal
+// below a path and confirm or deselect them before they are removed.
+package interactive
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/sirupsen/logrus"
+)
+
+// Decisions recorded in the log for each reviewed file.
+const (
+	DecisionConfirmed  string = "confirmed"
+	DecisionDeselected string = "deselected"
+)
+
+// ErrAborted indicates that the operator aborted the run while reviewing
+// the planned removals.
+var ErrAborted = errors.New("run aborted by operator")
+
+// ErrNotTerminal indicates that interactive mode was requested, but
+// standard input is not a terminal.
+var ErrNotTerminal = errors.New("interactive mode requires standard input to be a terminal")
+
+// Reviewer prompts the operator for decisions about planned removals. A
+// single Reviewer is used for all paths of a run so that buffered input is
+// not lost between paths.
+type Reviewer struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// New returns a Reviewer reading the decisions of the operator from in and
+// writing the planned removals and prompts to out.
+func New(in io.Reader, out io.Writer) *Reviewer {
+	return &Reviewer{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// Review shows the files planned for removal below the specified path and
+// returns the files whose removal the operator confirmed. Every decision is
+// recorded in the log. ErrAborted is returned if the operator aborts the run
+// or no further input is available.
+func (r *Reviewer) Review(config *config.Config, path string, files matches.FileMatches) (matches.FileMatches, error) {
+
+	log := config.GetLogger()
+	now := config.Now()
+
+	// Files are numbered by their position in the plan, which stays the same
+	// while files are deselected.
+	selected := make([]bool, len(files))
+	for i := range selected {
+		selected[i] = true
+	}
+
+	decide := func(i int, decision string) {
+		selected[i] = decision == DecisionConfirmed
+		log.WithFields(logrus.Fields{
+			"path":      path,
+			"file_size": files[i].SizeHR(),
+			"decision":  decision,
+		}).Info(matches.DisplayName(files[i].Path))
+	}
+
+	for {
+		remaining := countSelected(selected)
+		if remaining == 0 {
+			break
+		}
+
+		r.showTable(path, files, selected, now)
+
+		answer, err := r.ask("Remove these files? [a]ll, [e]ach, [d]eselect, [n]one, [q]uit: ")
+		if err != nil {
+			return nil, err
+		}
+
+		switch answer {
+		case "a", "all":
+			log.WithFields(logrus.Fields{
+				"path":  path,
+				"files": remaining,
+			}).Info("Operator confirmed removal of all selected files")
+
+			for i := range files {
+				if selected[i] {
+					decide(i, DecisionConfirmed)
+				}
+			}
+			return confirmedFiles(files, selected), nil
+
+		case "e", "each":
+			for i := range files {
+				if !selected[i] {
+					continue
+				}
+
+				decision, err := r.askFile(files[i], now)
+				if err != nil {
+					return nil, err
+				}
+				decide(i, decision)
+			}
+			return confirmedFiles(files, selected), nil
+
+		case "d", "deselect":
+			answer, err := r.ask("Files to deselect (e.g., 1,3-5): ")
+			if err != nil {
+				return nil, err
+			}
+
+			numbers, err := parseSelection(answer, len(files))
+			if err != nil {
+				fmt.Fprintf(r.out, "Invalid selection: %s\n", err)
+				continue
+			}
+
+			for _, number := range numbers {
+				if selected[number-1] {
+					decide(number-1, DecisionDeselected)
+				}
+			}
+
+		case "n", "none":
+			log.WithFields(logrus.Fields{
+				"path":  path,
+				"files": remaining,
+			}).Info("Operator deselected all remaining files")
+
+			for i := range files {
+				if selected[i] {
+					decide(i, DecisionDeselected)
+				}
+			}
+
+		case "q", "quit":
+			log.WithFields(logrus.Fields{
+				"path": path,
+			}).Warn("Operator aborted the run")
+			return nil, ErrAborted
+
+		default:
+			fmt.Fprintf(r.out, "Unknown choice %q\n", answer)
+		}
+	}
+
+	return nil, nil
+}
+
+// askFile prompts the operator for a decision about a single file.
+func (r *Reviewer) askFile(file matches.FileMatch, now time.Time) (string, error) {
+
+	prompt := fmt.Sprintf("Remove %s (%s, %s old)? [y]es, [n]o, [q]uit: ",
+		matches.DisplayName(file.Path), file.SizeHR(), formatAge(now.Sub(file.ModTime())))
+
+	for {
+		answer, err := r.ask(prompt)
+		if err != nil {
+			return "", err
+		}
+
+		switch answer {
+		case "y", "yes":
+			return DecisionConfirmed, nil
+		case "n", "no":
+			return DecisionDeselected, nil
+		case "q", "quit":
+			return "", ErrAborted
+		default:
+			fmt.Fprintf(r.out, "Unknown choice %q\n", answer)
+		}
+	}
+}
+
+// ask writes the prompt and returns the answer of the operator in lower
+// case. Running out of input aborts the run, as no further decisions can be
+// made.
+func (r *Reviewer) ask(prompt string) (string, error) {
+
+	fmt.Fprint(r.out, prompt)
+
+	line, err := r.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		fmt.Fprintln(r.out)
+		if errors.Is(err, io.EOF) {
+			return "", ErrAborted
+		}
+		return "", fmt.Errorf("unable to read answer: %w", err)
+	}
+
+	return strings.ToLower(strings.TrimSpace(line)), nil
+}
+
+// showTable writes the files still selected for removal below the path.
+func (r *Reviewer) showTable(path string, files matches.FileMatches, selected []bool, now time.Time) {
+
+	confirmed := confirmedFiles(files, selected)
+
+	fmt.Fprintf(r.out, "\nPlanned removals for path %q: %d files (%s)\n\n",
+		path, len(confirmed), confirmed.TotalFileSizeHR())
+
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  #\tSIZE\tAGE\tFILE")
+	for i, file := range files {
+		if !selected[i] {
+			continue
+		}
+		fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\n",
+			i+1, file.SizeHR(), formatAge(now.Sub(file.ModTime())), matches.DisplayName(file.Path))
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(r.out)
+}
+
+// confirmedFiles returns the files which are still selected for removal.
+func confirmedFiles(files matches.FileMatches, selected []bool) matches.FileMatches {
+
+	confirmed := make(matches.FileMatches, 0, countSelected(selected))
+	for i, file := range files {
+		if selected[i] {
+			confirmed = append(confirmed, file)
+		}
+	}
+
+	return confirmed
+}
+
+// countSelected returns the number of files still selected for removal.
+func countSelected(selected []bool) int {
+
+	var count int
+	for _, isSelected := range selected {
+		if isSelected {
+			count++
+		}
+	}
+
+	return count
+}
+
+// parseSelection parses a comma or space separated list of file numbers and
+// ranges of file numbers (e.g., "1,3-5"), each between 1 and limit.
+func parseSelection(selection string, limit int) ([]int, error) {
+
+	fields := strings.FieldsFunc(selection, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no files specified")
+	}
+
+	var numbers []int
+
+	for _, field := range fields {
+		first, last, isRange := strings.Cut(field, "-")
+		if !isRange {
+			last = first
+		}
+
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file number %q", field)
+		}
+
+		end, err := strconv.Atoi(last)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file number %q", field)
+		}
+
+		if start < 1 || end > limit || start > end {
+			return nil, fmt.Errorf("file numbers %q out of range 1-%d", field, limit)
+		}
+
+		for number := start; number <= end; number++ {
+			numbers = append(numbers, number)
+		}
+	}
+
+	return numbers, nil
+}
+
+// formatAge returns a short representation of the age of a file, e.g.,
+// "12d 3h" or "45m".
+func formatAge(age time.Duration) string {
+
+	if age < 0 {
+		age = 0
+	}
+
+	days := int(age / (24 * time.Hour))
+	hours := int(age % (24 * time.Hour) / time.Hour)
+	minutes := int(age % time.Hour / time.Minute)
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interactive
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+)
+
+func TestReview(t *testing.T) {
+
+	dir := t.TempDir()
+
+	var files matches.FileMatches
+	for _, name := range []string{"a.log", "b.log", "c.log", "d.log"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("interactive test\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, matches.FileMatch{FileInfo: info, Path: path})
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		confirmed []string
+		err       error
+	}{
+		{
+			name:      "Confirm all",
+			input:     "a\n",
+			confirmed: []string{"a.log", "b.log", "c.log", "d.log"},
+		},
+		{
+			name:  "Deselect all",
+			input: "n\n",
+		},
+		{
+			name:      "Confirm each",
+			input:     "e\ny\nn\nmaybe\nyes\nno\n",
+			confirmed: []string{"a.log", "c.log"},
+		},
+		{
+			name:      "Deselect files, then confirm remaining",
+			input:     "d\n2-3\nd\n5\nd\n3,4\nall\n",
+			confirmed: []string{"a.log"},
+		},
+		{
+			name:      "Unknown choice",
+			input:     "x\nA\n",
+			confirmed: []string{"a.log", "b.log", "c.log", "d.log"},
+		},
+		{
+			name:  "Quit",
+			input: "d\n1\nq\n",
+			err:   ErrAborted,
+		},
+		{
+			name:  "Quit while confirming each",
+			input: "e\ny\nq\n",
+			err:   ErrAborted,
+		},
+		{
+			name:  "Out of input",
+			input: "e\ny\n",
+			err:   ErrAborted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.NewDefaultConfig()
+			var logOutput bytes.Buffer
+			c.GetLogger().SetOutput(&logOutput)
+
+			var output bytes.Buffer
+			confirmed, err := New(strings.NewReader(tt.input), &output).Review(&c, dir, files)
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, expected %v", err, tt.err)
+			}
+
+			var names []string
+			for _, file := range confirmed {
+				names = append(names, filepath.Base(file.Path))
+			}
+			if !reflect.DeepEqual(names, tt.confirmed) {
+				t.Errorf("got confirmed files %v, expected %v", names, tt.confirmed)
+			}
+
+			if !strings.Contains(output.String(), "Planned removals for path") {
+				t.Errorf("planned removals not shown: %s", output.String())
+			}
+
+			// Every decision is logged.
+			decisions := strings.Count(logOutput.String(), "decision=")
+			if err == nil && decisions != len(files) {
+				t.Errorf("got %d logged decisions, expected %d: %s", decisions, len(files), logOutput.String())
+			}
+		})
+	}
+}
+
+func TestReviewReadError(t *testing.T) {
+
+	c := config.NewDefaultConfig()
+	c.GetLogger().SetOutput(io.Discard)
+
+	readErr := errors.New("read failed")
+	files := matches.FileMatches{{Path: "/tmp/elbow/a.log", FileInfo: fakeFileInfo{}}}
+
+	_, err := New(failingReader{err: readErr}, io.Discard).Review(&c, "/tmp/elbow", files)
+	if !errors.Is(err, readErr) {
+		t.Errorf("got error %v, expected %v", err, readErr)
+	}
+}
+
+func TestParseSelection(t *testing.T) {
+
+	tests := []struct {
+		selection string
+		numbers   []int
+		wantErr   bool
+	}{
+		{selection: "1", numbers: []int{1}},
+		{selection: "1,3-5", numbers: []int{1, 3, 4, 5}},
+		{selection: " 2 4, 5-5 ", numbers: []int{2, 4, 5}},
+		{selection: "", wantErr: true},
+		{selection: "0", wantErr: true},
+		{selection: "6", wantErr: true},
+		{selection: "4-2", wantErr: true},
+		{selection: "1-x", wantErr: true},
+		{selection: "a", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		numbers, err := parseSelection(tt.selection, 5)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("selection %q: got error %v, expected error: %t", tt.selection, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(numbers, tt.numbers) {
+			t.Errorf("selection %q: got %v, expected %v", tt.selection, numbers, tt.numbers)
+		}
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+
+	tests := map[time.Duration]string{
+		-time.Hour:                     "0m",
+		45 * time.Minute:               "45m",
+		3*time.Hour + 5*time.Minute:    "3h 5m",
+		12*24*time.Hour + 3*time.Hour:  "12d 3h",
+		400*24*time.Hour + time.Minute: "400d 0h",
+	}
+
+	for age, want := range tests {
+		if got := formatAge(age); got != want {
+			t.Errorf("age %s: got %q, expected %q", age, got, want)
+		}
+	}
+}
+
+// failingReader returns the specified error for every read.
+type failingReader struct {
+	err error
+}
+
+func (r failingReader) Read(_ []byte) (int, error) {
+	return 0, r.err
+}
+
+// fakeFileInfo describes an empty file.
+type fakeFileInfo struct{}
+
+func (fakeFileInfo) Name() string       { return "a.log" }
+func (fakeFileInfo) Size() int64        { return 0 }
+func (fakeFileInfo) Mode() os.FileMode  { return 0 }
+func (fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (fakeFileInfo) IsDir() bool        { return false }
+func (fakeFileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,408 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package journal records the files planned for removal by an application
+// run along with the outcome of handling each of them. The journal is kept
+// on disk so that a run which was interrupted (e.g., killed or stopped by an
+// error) can be resumed later, handling exactly the files originally planned
+// instead of re-evaluating each path.
+//
+// Each run is recorded in its own file within the journal directory, named
+// after the run ID. Records are appended as JSON lines: a header for the
+// run, the planned files for each path (or lifecycle stage of a path), one
+// record for each file handled and a final record once the run completes.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileExtension is the extension of journal files stored in the journal
+// directory.
+const FileExtension string = ".jsonl"
+
+// syncBatch is the number of file records written before the journal is
+// flushed to disk. Plans and the completion of a run are always flushed
+// immediately.
+const syncBatch int = 64
+
+// Types of records stored in a journal.
+const (
+	RecordRun      string = "run"
+	RecordPlan     string = "plan"
+	RecordDone     string = "done"
+	RecordResume   string = "resume"
+	RecordComplete string = "complete"
+)
+
+// ErrNotFound indicates that no journal was found for the requested run.
+var ErrNotFound = errors.New("no journal found for run")
+
+// File represents a file planned for removal along with the attributes
+// identifying it when it was found.
+type File struct {
+	Path    string    `json:"path"`
+	Device  uint64    `json:"device"`
+	Inode   uint64    `json:"inode"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Record represents a single line of a journal. Fields not relevant to the
+// type of record are omitted.
+type Record struct {
+	Type  string    `json:"type"`
+	RunID string    `json:"run_id"`
+	Time  time.Time `json:"time"`
+
+	// Settings used by the run in TOML format (run records only).
+	Config string `json:"config,omitempty"`
+
+	// Search path (plan records) or file (done records).
+	Path string `json:"path,omitempty"`
+
+	// Position of the lifecycle stage within the configured stages, if the
+	// plan applies to a stage (plan records only).
+	Stage *int `json:"stage,omitempty"`
+
+	// Files planned for removal (plan records only).
+	Files []File `json:"files,omitempty"`
+
+	// Outcome of handling the file (done records only).
+	Status string `json:"status,omitempty"`
+}
+
+// Plan represents the files planned for removal below a single path (or for
+// a single lifecycle stage of the path).
+type Plan struct {
+	Path  string
+	Stage *int
+	Files []File
+}
+
+// Run represents the content of the journal for a single application run.
+type Run struct {
+	RunID    string
+	Config   string
+	Plans    []Plan
+	Complete bool
+
+	// Outcome recorded for each handled file, indexed by path.
+	Done map[string]string
+}
+
+// Pending returns the files of the plan which have not been handled yet.
+func (r Run) Pending(plan Plan) []File {
+
+	var pending []File
+
+	for _, file := range plan.Files {
+		if _, done := r.Done[file.Path]; !done {
+			pending = append(pending, file)
+		}
+	}
+
+	return pending
+}
+
+// Journal records the progress of a single application run. All methods may
+// be called concurrently and are no-ops on a nil Journal, so that
+// journaling can be disabled by not creating one.
+type Journal struct {
+	mu      sync.Mutex
+	fh      *os.File
+	runID   string
+	pending int
+
+	// First error encountered writing the journal, reported once closed.
+	err error
+}
+
+// Path returns the path of the journal file for the specified run within
+// the journal directory.
+func Path(dir string, runID string) string {
+	return filepath.Join(dir, runID+FileExtension)
+}
+
+// Create creates the journal for a new run within the journal directory,
+// recording the settings used by the run.
+func Create(dir string, runID string, settings string) (*Journal, error) {
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create journal directory: %w", err)
+	}
+
+	fh, err := os.OpenFile(filepath.Clean(Path(dir, runID)), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create journal: %w", err)
+	}
+
+	j := &Journal{fh: fh, runID: runID}
+
+	if err := j.write(Record{Type: RecordRun, Config: settings}, true); err != nil {
+		_ = fh.Close()
+		return nil, err
+	}
+
+	// Flush the directory entry so that the journal itself survives a crash.
+	if err := syncDir(dir); err != nil {
+		_ = fh.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Open opens the existing journal of an interrupted run within the journal
+// directory so that the run can be resumed.
+func Open(dir string, runID string) (*Journal, error) {
+
+	fh, err := os.OpenFile(filepath.Clean(Path(dir, runID)), os.O_APPEND|os.O_WRONLY, 0600)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w %s", ErrNotFound, runID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to open journal: %w", err)
+	}
+
+	j := &Journal{fh: fh, runID: runID}
+
+	if err := j.write(Record{Type: RecordResume}, true); err != nil {
+		_ = fh.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Plan records the files planned for removal below the specified path, or
+// for the specified lifecycle stage of the path if stage is not nil. The
+// plan is flushed to disk before returning so that no file is handled
+// before its plan has been recorded.
+func (j *Journal) Plan(path string, stage *int, files []File) error {
+	if j == nil {
+		return nil
+	}
+	return j.write(Record{Type: RecordPlan, Path: path, Stage: stage, Files: files}, true)
+}
+
+// Done records the outcome of handling the specified file. Records are
+// flushed to disk in batches.
+func (j *Journal) Done(path string, status string) {
+	if j == nil {
+		return
+	}
+	_ = j.write(Record{Type: RecordDone, Path: path, Status: status}, false)
+}
+
+// Complete records that the run completed and closes the journal. A
+// completed run cannot be resumed.
+func (j *Journal) Complete() error {
+	if j == nil {
+		return nil
+	}
+
+	if err := j.write(Record{Type: RecordComplete}, true); err != nil {
+		_ = j.Close()
+		return err
+	}
+
+	return j.Close()
+}
+
+// Close flushes any pending records and closes the journal without marking
+// the run as completed. The first error encountered writing the journal is
+// returned.
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.fh == nil {
+		return j.err
+	}
+
+	if j.pending > 0 {
+		if err := j.fh.Sync(); err != nil && j.err == nil {
+			j.err = fmt.Errorf("unable to flush journal: %w", err)
+		}
+	}
+
+	if err := j.fh.Close(); err != nil && j.err == nil {
+		j.err = fmt.Errorf("unable to close journal: %w", err)
+	}
+	j.fh = nil
+
+	return j.err
+}
+
+// write appends the record to the journal, flushing the journal to disk if
+// requested or once a batch of records is pending.
+func (j *Journal) write(record Record, flush bool) error {
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.err != nil {
+		return j.err
+	}
+
+	if j.fh == nil {
+		j.err = fmt.Errorf("journal for run %s already closed", j.runID)
+		return j.err
+	}
+
+	record.RunID = j.runID
+	record.Time = time.Now()
+
+	content, err := json.Marshal(record)
+	if err != nil {
+		j.err = fmt.Errorf("unable to encode journal record: %w", err)
+		return j.err
+	}
+
+	if _, err := j.fh.Write(append(content, '\n')); err != nil {
+		j.err = fmt.Errorf("unable to write journal: %w", err)
+		return j.err
+	}
+
+	j.pending++
+
+	if flush || j.pending >= syncBatch {
+		if err := j.fh.Sync(); err != nil {
+			j.err = fmt.Errorf("unable to flush journal: %w", err)
+			return j.err
+		}
+		j.pending = 0
+	}
+
+	return nil
+}
+
+// Load reads the journal of the specified run from the journal directory.
+// A partially written final record, as left behind by a crash, is ignored.
+func Load(dir string, runID string) (Run, error) {
+
+	journalPath := Path(dir, runID)
+
+	fh, err := os.Open(filepath.Clean(journalPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return Run{}, fmt.Errorf("%w %s", ErrNotFound, runID)
+	}
+	if err != nil {
+		return Run{}, fmt.Errorf("unable to open journal: %w", err)
+	}
+	defer fh.Close()
+
+	run := Run{
+		RunID: runID,
+		Done:  make(map[string]string),
+	}
+
+	var lines []string
+
+	scanner := bufio.NewScanner(fh)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Run{}, fmt.Errorf("unable to read journal %s: %w", journalPath, err)
+	}
+
+	for i, line := range lines {
+		var record Record
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			if i == len(lines)-1 {
+				break
+			}
+			return Run{}, fmt.Errorf("unable to parse journal %s: %w", journalPath, err)
+		}
+
+		switch record.Type {
+		case RecordRun:
+			run.Config = record.Config
+		case RecordPlan:
+			run.Plans = append(run.Plans, Plan{
+				Path:  record.Path,
+				Stage: record.Stage,
+				Files: record.Files,
+			})
+		case RecordDone:
+			run.Done[record.Path] = record.Status
+		case RecordComplete:
+			run.Complete = true
+		}
+	}
+
+	if run.Config == "" {
+		return Run{}, fmt.Errorf("journal %s does not record the settings of the run", journalPath)
+	}
+
+	return run, nil
+}
+
+// Incomplete returns the IDs of all runs recorded in the journal directory
+// which did not complete, oldest first.
+func Incomplete(dir string) ([]string, error) {
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read journal directory: %w", err)
+	}
+
+	var runIDs []string
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), FileExtension) {
+			continue
+		}
+
+		runID := strings.TrimSuffix(entry.Name(), FileExtension)
+
+		run, err := Load(dir, runID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !run.Complete {
+			runIDs = append(runIDs, runID)
+		}
+	}
+
+	// Run IDs start with a timestamp.
+	sort.Strings(runIDs)
+
+	return runIDs, nil
+}
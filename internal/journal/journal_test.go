@@ -0,0 +1,188 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journal
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestJournalRoundTrip(t *testing.T) {
+
+	dir := t.TempDir()
+	runID := "20260102T150405Z-1a2b3c4d"
+	stage := 1
+
+	j, err := Create(dir, runID, "remove = true\n")
+	if err != nil {
+		t.Fatalf("unable to create journal: %v", err)
+	}
+
+	files := []File{
+		{Path: "/tmp/elbow/a.log", Inode: 1, Size: 3},
+		{Path: "/tmp/elbow/b.log", Inode: 2, Size: 3},
+		{Path: "/tmp/elbow/c.log", Inode: 3, Size: 3},
+	}
+
+	if err := j.Plan("/tmp/elbow", &stage, files); err != nil {
+		t.Fatalf("unable to record plan: %v", err)
+	}
+	j.Done(files[0].Path, "succeeded")
+	j.Done(files[2].Path, "skipped")
+
+	if _, err := Create(dir, runID, "remove = true\n"); err == nil {
+		t.Error("journal for existing run created again")
+	}
+
+	if err := j.Close(); err != nil {
+		t.Fatalf("unable to close journal: %v", err)
+	}
+
+	run, err := Load(dir, runID)
+	if err != nil {
+		t.Fatalf("unable to load journal: %v", err)
+	}
+
+	if run.Config != "remove = true\n" {
+		t.Errorf("got settings %q, expected %q", run.Config, "remove = true\n")
+	}
+
+	if run.Complete {
+		t.Error("closed run reported as complete")
+	}
+
+	if len(run.Plans) != 1 || run.Plans[0].Stage == nil || *run.Plans[0].Stage != stage {
+		t.Fatalf("got plans %+v, expected one plan for stage %d", run.Plans, stage)
+	}
+
+	if got := run.Pending(run.Plans[0]); !reflect.DeepEqual(got, files[1:2]) {
+		t.Errorf("got pending files %+v, expected %+v", got, files[1:2])
+	}
+
+	incomplete, err := Incomplete(dir)
+	if err != nil {
+		t.Fatalf("unable to list incomplete runs: %v", err)
+	}
+	if !reflect.DeepEqual(incomplete, []string{runID}) {
+		t.Errorf("got incomplete runs %v, expected %v", incomplete, []string{runID})
+	}
+
+	j, err = Open(dir, runID)
+	if err != nil {
+		t.Fatalf("unable to reopen journal: %v", err)
+	}
+	j.Done(files[1].Path, "succeeded")
+	if err := j.Complete(); err != nil {
+		t.Fatalf("unable to complete run: %v", err)
+	}
+
+	run, err = Load(dir, runID)
+	if err != nil {
+		t.Fatalf("unable to load journal: %v", err)
+	}
+
+	if !run.Complete {
+		t.Error("completed run not reported as complete")
+	}
+
+	if got := run.Pending(run.Plans[0]); len(got) != 0 {
+		t.Errorf("got pending files %+v for completed run", got)
+	}
+
+	incomplete, err = Incomplete(dir)
+	if err != nil {
+		t.Fatalf("unable to list incomplete runs: %v", err)
+	}
+	if len(incomplete) != 0 {
+		t.Errorf("got incomplete runs %v, expected none", incomplete)
+	}
+}
+
+func TestLoad(t *testing.T) {
+
+	runRecord := `{"type":"run","run_id":"r","config":"remove = true\n"}` + "\n"
+	planRecord := `{"type":"plan","run_id":"r","path":"/tmp/elbow","files":[{"path":"/tmp/elbow/a.log"}]}` + "\n"
+
+	tests := []struct {
+		name    string
+		content string
+		plans   int
+		wantErr bool
+	}{
+		{
+			name:    "Complete records",
+			content: runRecord + planRecord,
+			plans:   1,
+		},
+		{
+			name:    "Partially written final record",
+			content: runRecord + planRecord + `{"type":"done","run_id":"r","pa`,
+			plans:   1,
+		},
+		{
+			name:    "Corrupt record before final record",
+			content: runRecord + `{"type":"pl` + "\n" + planRecord,
+			wantErr: true,
+		},
+		{
+			name:    "Missing run record",
+			content: planRecord,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			if err := os.WriteFile(Path(dir, "r"), []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			run, err := Load(dir, "r")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, expected error: %t", err, tt.wantErr)
+			}
+
+			if err == nil && len(run.Plans) != tt.plans {
+				t.Errorf("got %d plans, expected %d", len(run.Plans), tt.plans)
+			}
+		})
+	}
+
+	if _, err := Load(t.TempDir(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, expected %v", err, ErrNotFound)
+	}
+}
+
+func TestNilJournal(t *testing.T) {
+
+	var j *Journal
+
+	if err := j.Plan("/tmp/elbow", nil, nil); err != nil {
+		t.Errorf("got error %v recording plan in nil journal", err)
+	}
+	j.Done("/tmp/elbow/a.log", "succeeded")
+	if err := j.Complete(); err != nil {
+		t.Errorf("got error %v completing nil journal", err)
+	}
+	if err := j.Close(); err != nil {
+		t.Errorf("got error %v closing nil journal", err)
+	}
+}
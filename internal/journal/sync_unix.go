@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// syncDir flushes the entries of the specified directory to disk.
+func syncDir(dir string) error {
+
+	fh, err := os.Open(filepath.Clean(dir))
+	if err != nil {
+		return fmt.Errorf("unable to open journal directory: %w", err)
+	}
+
+	if err := fh.Sync(); err != nil {
+		_ = fh.Close()
+		return fmt.Errorf("unable to flush journal directory: %w", err)
+	}
+
+	return fh.Close()
+}
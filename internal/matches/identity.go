@@ -0,0 +1,91 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matches
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Identity records the attributes identifying a file as it was found when
+// searching a path. Comparing it against the file found at the same path
+// later on detects files replaced, rotated or rewritten in the meantime.
+type Identity struct {
+
+	// Device and Inode identify the file on platforms which expose them;
+	// both are zero elsewhere.
+	Device uint64
+	Inode  uint64
+
+	Size    int64
+	ModTime time.Time
+}
+
+// NewIdentity returns the identity of the file described by the provided
+// file metadata.
+func NewIdentity(info os.FileInfo) Identity {
+
+	device, inode := fileID(info)
+
+	return Identity{
+		Device:  device,
+		Inode:   inode,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+}
+
+// IsZero indicates whether the identity has not been recorded.
+func (id Identity) IsZero() bool {
+	return id == Identity{}
+}
+
+// SameFile returns the identity without the size and modification time of
+// the file, so that comparing it only detects files replaced in the
+// meantime. Content written to the file since it was found (e.g., by a
+// process still holding it open) is ignored.
+func (id Identity) SameFile() Identity {
+	return Identity{Device: id.Device, Inode: id.Inode}
+}
+
+// Compare returns a description of the first difference between the
+// identity and the provided identity of the file currently found at the same
+// path. An empty string is returned if both identify the same file. Size and
+// modification time are not compared for identities returned by SameFile.
+func (id Identity) Compare(current Identity) string {
+
+	switch {
+	case id.Device != current.Device || id.Inode != current.Inode:
+		return fmt.Sprintf(
+			"file replaced (device/inode %d/%d, expected %d/%d)",
+			current.Device, current.Inode, id.Device, id.Inode,
+		)
+	case id.ModTime.IsZero():
+		return ""
+	case id.Size != current.Size:
+		return fmt.Sprintf("size changed (%d bytes, expected %d)", current.Size, id.Size)
+	case !id.ModTime.Equal(current.ModTime):
+		return fmt.Sprintf(
+			"modification time changed (%s, expected %s)",
+			current.ModTime.Format(time.RFC3339Nano),
+			id.ModTime.Format(time.RFC3339Nano),
+		)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+// +build !windows
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matches
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID returns the device and inode numbers of the file described by the
+// provided file metadata.
+func fileID(info os.FileInfo) (uint64, uint64) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0
+	}
+
+	// The device number type differs between platforms.
+	return uint64(stat.Dev), stat.Ino // nolint:unconvert
+}
@@ -0,0 +1,31 @@
+//go:build windows
+// +build windows
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matches
+
+import (
+	"os"
+)
+
+// fileID returns zero device and inode numbers as they are not exposed via
+// file metadata on Windows. Files are identified by size and modification
+// time only.
+func fileID(_ os.FileInfo) (uint64, uint64) {
+	return 0, 0
+}
@@ -19,11 +19,18 @@
 package matches
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/atc0005/elbow/internal/config"
 	"github.com/atc0005/elbow/internal/units"
@@ -35,6 +42,7 @@ import (
 type FileAgeThreshold struct {
 	daysBack int
 	time     time.Time
+	calendar bool
 }
 
 // String implements the Stringer interface for display purposes.
@@ -43,9 +51,10 @@ func (ft FileAgeThreshold) String() string {
 }
 
 // FormatDisplay returns the file age threshold in a human friendly time
-// format for display purposes.
+// format for display purposes. The time zone used to calculate the threshold
+// is included.
 func (ft FileAgeThreshold) FormatDisplay() string {
-	return ft.time.Format(time.RFC1123)
+	return fmt.Sprintf("%s (%s)", ft.time.Format(time.RFC1123), ft.time.Location())
 }
 
 // FormatLog returns the file age threshold in a format intended for use in
@@ -65,18 +74,53 @@ func (ft FileAgeThreshold) Time() time.Time {
 	return ft.time
 }
 
-// NewFileAgeThreshold is used to create a new instance of FileAgeThreshold.
-func NewFileAgeThreshold(daysOld int) FileAgeThreshold {
+// Calendar indicates whether the file age threshold is aligned to midnight.
+func (ft FileAgeThreshold) Calendar() bool {
+	return ft.calendar
+}
+
+// NewFileAgeThreshold is used to create a new instance of FileAgeThreshold
+// relative to the reference time provided by the configuration (the current
+// time unless an "as of" time was specified). The calendar alignment and time
+// zone settings from the provided configuration are applied.
+func NewFileAgeThreshold(daysOld int, config *config.Config) FileAgeThreshold {
+	return NewFileAgeThresholdFrom(daysOld, config.Now(), config)
+}
+
+// NewFileAgeThresholdFrom is used to create a new instance of
+// FileAgeThreshold relative to the specified reference time instead of the
+// current time.
+//
+// If calendar mode is enabled the threshold is aligned to midnight in the
+// configured time zone, so that only files last modified before the day
+// daysOld days prior to the reference time are eligible. This provides the
+// same results regardless of the time of day the reference time falls on.
+func NewFileAgeThresholdFrom(daysOld int, reference time.Time, config *config.Config) FileAgeThreshold {
 
 	// Flip user specified number of days negative so that we can wind
 	// back that many days from the file modification time. This gives
 	// us our threshold to compare file modification times against.
 	daysBack := -(daysOld)
-	fileAgeThreshold := time.Now().AddDate(0, 0, daysBack)
+
+	reference = reference.In(config.GetLocation())
+
+	var fileAgeThreshold time.Time
+	switch {
+	case config.GetCalendarAge():
+		// Using time.Date instead of subtracting durations keeps the
+		// threshold at midnight across daylight saving time transitions.
+		year, month, day := reference.Date()
+		fileAgeThreshold = time.Date(
+			year, month, day+daysBack, 0, 0, 0, 0, reference.Location(),
+		)
+	default:
+		fileAgeThreshold = reference.AddDate(0, 0, daysBack)
+	}
 
 	return FileAgeThreshold{
 		daysBack: daysBack,
 		time:     fileAgeThreshold,
+		calendar: config.GetCalendarAge(),
 	}
 }
 
@@ -87,6 +131,44 @@ func NewFileAgeThreshold(daysOld int) FileAgeThreshold {
 type FileMatch struct {
 	os.FileInfo
 	Path string
+
+	// ContentType is the MIME type detected for the file. This is only
+	// populated if content type matching was requested.
+	ContentType string
+
+	// ProtectedReason records why a file that meets all criteria must not be
+	// removed. Protected files still count toward the number of files to
+	// keep, but are never selected for pruning.
+	ProtectedReason string
+
+	// SkipReason records why a file selected for pruning was skipped, such
+	// as a failed write-stability check.
+	SkipReason string
+
+	// Identity records the file as it was found when searching the path so
+	// that it can be confirmed to be the same file before it is removed.
+	Identity Identity
+}
+
+// Reasons recorded for protected files.
+const (
+
+	// ProtectedReasonPinned indicates that the file has been pinned via an
+	// extended attribute.
+	ProtectedReasonPinned string = "pinned"
+
+	// ProtectedReasonSymlinkTarget indicates that the file is the target of
+	// a symlink (e.g., a "current" or "latest" link).
+	ProtectedReasonSymlinkTarget string = "symlink target"
+
+	// ProtectedReasonApplicationFile indicates that the file is used by this
+	// application (e.g., its configuration or log file).
+	ProtectedReasonApplicationFile string = "application file"
+)
+
+// Protected indicates whether the file has been protected from removal.
+func (fm FileMatch) Protected() bool {
+	return fm.ProtectedReason != ""
 }
 
 // FileMatches is a slice of FileMatch objects that represents the search
@@ -113,6 +195,77 @@ func (fm FileMatches) TotalFileSizeHR() string {
 	return units.ByteCountIEC(fm.TotalFileSize())
 }
 
+// ProtectedFiles returns the subset of files that have been protected from
+// removal.
+func (fm FileMatches) ProtectedFiles() FileMatches {
+
+	var protected FileMatches
+
+	for _, file := range fm {
+		if file.Protected() {
+			protected = append(protected, file)
+		}
+	}
+
+	return protected
+}
+
+// Protect marks each file whose canonical path (or the canonical path of any
+// parent directory) is present in the provided set of canonical paths as
+// protected using the specified reason, so that directories in the set
+// protect all files below them. Files which are already protected retain
+// their original reason. The number of newly protected files is returned.
+func (fm FileMatches) Protect(canonicalPaths map[string]struct{}, reason string) int {
+
+	var count int
+
+	for i := range fm {
+		if fm[i].Protected() {
+			continue
+		}
+
+		canonical, err := CanonicalPath(fm[i].Path)
+		if err != nil {
+			continue
+		}
+
+		for path := canonical; ; {
+			if _, ok := canonicalPaths[path]; ok {
+				fm[i].ProtectedReason = reason
+				count++
+				break
+			}
+
+			parent := filepath.Dir(path)
+			if parent == path {
+				break
+			}
+			path = parent
+		}
+	}
+
+	return count
+}
+
+// CanonicalPath returns an absolute path for the specified file with any
+// symlinks in the parent directories resolved. The final path element is
+// intentionally not resolved so that a symlink and its target have distinct
+// canonical paths.
+func CanonicalPath(path string) (string, error) {
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Dir(absPath))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, filepath.Base(absPath)), nil
+}
+
 // SizeHR returns a human-readable string of the size of a FileMatch object.
 func (fm FileMatch) SizeHR() string {
 	return units.ByteCountIEC(fm.Size())
@@ -125,29 +278,37 @@ func HasMatchingExtension(filename string, config *config.Config) bool {
 
 	log := config.GetLogger()
 
+	if !utf8.ValidString(filename) {
+		log.Debugf("HasMatchingExtension: %s is not valid UTF-8", DisplayName(filename))
+	}
+
 	ext := filepath.Ext(filename)
 	ext = strings.TrimPrefix(ext, ".")
+	ext = NormalizeName(ext, config)
 
 	// handle empty extensions list scenario
 	if len(config.GetFileExtensions()) == 0 {
 		log.Debug("No extension limits have been set!")
-		log.Debugf("Considering %s safe for removal", filename)
+		log.Debugf("Considering %s safe for removal", DisplayName(filename))
 		return true
 	}
 
 	log.Debug("Removing leading dot from specified file extensions for comparison")
 	fileExtensions := make([]string, 0, len(config.GetFileExtensions()))
 	for _, fileExt := range config.GetFileExtensions() {
-		fileExtensions = append(fileExtensions, strings.TrimPrefix(fileExt, "."))
+		fileExtensions = append(
+			fileExtensions,
+			NormalizeName(strings.TrimPrefix(fileExt, "."), config),
+		)
 	}
 
 	log.Debug("Comparing extensions case-insensitively")
 	if InList(ext, fileExtensions, true) {
-		log.Debugf("%s has a valid extension for removal", filename)
+		log.Debugf("%s has a valid extension for removal", DisplayName(filename))
 		return true
 	}
 
-	log.Debug("HasMatchingExtension: returning false for:", filename)
+	log.Debug("HasMatchingExtension: returning false for:", DisplayName(filename))
 	log.Debugf("HasMatchingExtension: returning false (%q not in %q)",
 		ext, fileExtensions)
 	return false
@@ -162,53 +323,79 @@ func HasMatchingFilenamePattern(filename string, config *config.Config) bool {
 
 	if strings.TrimSpace(config.GetFilePattern()) == "" {
 		log.Debug("No FilePattern has been specified!")
-		log.Debugf("Considering %s safe for removal", filename)
+		log.Debugf("Considering %s safe for removal", DisplayName(filename))
 		return true
 	}
 
+	if !utf8.ValidString(filename) {
+		log.Debugf("HasMatchingFilenamePattern: %s is not valid UTF-8", DisplayName(filename))
+	}
+
+	// Apply the same normalization to both the filename and the pattern so
+	// that equivalent names compare as equal.
+	name := NormalizeName(filename, config)
+	pattern := NormalizeName(config.GetFilePattern(), config)
+
 	// Search for substring
-	if strings.Contains(filename, config.GetFilePattern()) {
-		log.Debug("HasMatchingFilenamePattern: returning true for:", filename)
+	if strings.Contains(name, pattern) {
+		log.Debug("HasMatchingFilenamePattern: returning true for:", DisplayName(filename))
 		log.Debugf("HasMatchingFilenamePattern: returning true (%q contains %q)",
-			filename, config.GetFilePattern())
+			name, pattern)
 		return true
 	}
 
-	log.Debug("HasMatchingFilenamePattern: returning false for:", filename)
+	log.Debug("HasMatchingFilenamePattern: returning false for:", DisplayName(filename))
 	log.Debugf("HasMatchingFilenamePattern: returning false (%q does not contain %q)",
-		filename, config.GetFilePattern())
+		name, pattern)
 	return false
 }
 
-// HasMatchingAge validates whether a file matches the desired age threshold
+// HasMatchingAge validates whether a file matches the desired age threshold.
+// Age is measured from the reference time provided by the configuration; this
+// is the current time unless an "as of" time was specified.
 func HasMatchingAge(file os.FileInfo, config *config.Config) bool {
+	return hasMatchingAge(file, config.Now(), config)
+}
+
+// HasMatchingRelativeAge validates whether a file matches the desired age
+// threshold when age is measured relative to the modification time of the
+// newest matching file instead of the current time. This prevents all copies
+// of a file from aging out if whatever produces them stops.
+func HasMatchingRelativeAge(file os.FileInfo, newest time.Time, config *config.Config) bool {
+	return hasMatchingAge(file, newest, config)
+}
+
+// hasMatchingAge validates whether a file matches the desired age threshold
+// calculated from the specified reference time.
+func hasMatchingAge(file os.FileInfo, reference time.Time, config *config.Config) bool {
 
 	log := config.GetLogger()
 
 	// used by this function's context logger and for return code
 	var ageCheckResults bool
 
-	now := time.Now()
 	fileModTime := file.ModTime()
 
 	// common fields that we can apply to all messages in this function
 	contextLogger := log.WithFields(logrus.Fields{
-		"file_mod_time": fileModTime.Format(time.RFC3339),
-		"current_time":  now.Format(time.RFC3339),
-		"file_age_flag": config.GetFileAge(),
-		"filename":      file.Name(),
+		"file_mod_time":  fileModTime.Format(time.RFC3339),
+		"reference_time": reference.Format(time.RFC3339),
+		"relative_age":   config.GetRelativeAge(),
+		"file_age_flag":  config.GetFileAge(),
+		"filename":       DisplayName(file.Name()),
 	})
 
 	// The default for this flag is 0, so only a positive, non-zero number
 	// is considered for use with age matching.
 	if config.GetFileAge() > 0 {
 
-		fileAgeThreshold := NewFileAgeThreshold(config.GetFileAge())
+		fileAgeThreshold := NewFileAgeThresholdFrom(config.GetFileAge(), reference, config)
 
 		// Bundle more fields now that we have access to the data
 		contextLogger = contextLogger.WithFields(logrus.Fields{
 			"file_age_threshold": fileAgeThreshold.FormatLog(),
 			"days_back":          fileAgeThreshold.DaysBack(),
+			"calendar_age":       fileAgeThreshold.Calendar(),
 		})
 
 		contextLogger.Debug("Before age check")
@@ -246,6 +433,170 @@ func HasMatchingAge(file os.FileInfo, config *config.Config) bool {
 
 }
 
+// HasMatchingContent validates whether the content of a file contains the
+// desired literal string and/or matches the desired regular expression. Only
+// a bounded range of the file (the first or last N bytes) is read. If no
+// content criteria are specified, the file being evaluated is considered
+// eligible for removal.
+//
+// Because this check requires opening and reading the file it is intended to
+// be applied only after the cheaper metadata checks have passed.
+func HasMatchingContent(path string, config *config.Config) bool {
+
+	log := config.GetLogger()
+
+	contentPattern := config.GetContentPattern()
+	contentRegexp := config.GetContentRegexp()
+
+	if contentPattern == "" && contentRegexp == nil {
+		log.Debug("No content criteria have been specified!")
+		log.Debugf("Considering %s safe for removal", path)
+		return true
+	}
+
+	contextLogger := log.WithFields(logrus.Fields{
+		"filename":         path,
+		"content_pattern":  contentPattern,
+		"content_regex":    config.GetContentRegex(),
+		"content_bytes":    config.GetContentBytes(),
+		"content_from_end": config.GetContentFromEnd(),
+	})
+
+	content, err := ReadContentRange(path, config.GetContentBytes(), config.GetContentFromEnd())
+	if err != nil {
+		contextLogger.Warnf("HasMatchingContent: unable to read file content: %s", err)
+		return false
+	}
+
+	if contentPattern != "" && !bytes.Contains(content, []byte(contentPattern)) {
+		contextLogger.Debug("HasMatchingContent: returning false (content pattern not found)")
+		return false
+	}
+
+	if contentRegexp != nil && !contentRegexp.Match(content) {
+		contextLogger.Debug("HasMatchingContent: returning false (content regex not matched)")
+		return false
+	}
+
+	contextLogger.Debug("HasMatchingContent: returning true")
+	return true
+}
+
+// contentSniffLen is the number of bytes considered by http.DetectContentType
+// when detecting the content type of a file.
+const contentSniffLen = 512
+
+// DetectContentType detects the MIME type of the specified file by sniffing
+// the first bytes of its content. Any parameters (e.g., charset) are removed
+// from the returned media type.
+func DetectContentType(path string) (string, error) {
+
+	content, err := ReadContentRange(path, contentSniffLen, false)
+	if err != nil {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(content)
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType, nil
+	}
+
+	return mediaType, nil
+}
+
+// HasMatchingContentType validates whether the detected content type of a
+// file matches one of the desired MIME types. A type may use a wildcard
+// subtype (e.g., text/*). The detected content type is returned along with
+// the result. If no content types are specified, the file being evaluated is
+// considered eligible for removal and detection is skipped.
+func HasMatchingContentType(path string, config *config.Config) (string, bool) {
+
+	log := config.GetLogger()
+
+	if len(config.GetContentTypes()) == 0 {
+		log.Debug("No content type limits have been set!")
+		log.Debugf("Considering %s safe for removal", path)
+		return "", true
+	}
+
+	contentType, err := DetectContentType(path)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"filename": path,
+		}).Warnf("HasMatchingContentType: unable to detect content type: %s", err)
+		return "", false
+	}
+
+	contextLogger := log.WithFields(logrus.Fields{
+		"filename":      path,
+		"content_type":  contentType,
+		"content_types": config.GetContentTypes(),
+	})
+
+	for _, wanted := range config.GetContentTypes() {
+		if contentTypeMatches(contentType, wanted) {
+			contextLogger.Debug("HasMatchingContentType: returning true")
+			return contentType, true
+		}
+	}
+
+	contextLogger.Debug("HasMatchingContentType: returning false")
+	return contentType, false
+}
+
+// contentTypeMatches compares a detected media type against a desired type,
+// honoring wildcard subtypes. Comparisons are performed case-insensitively.
+func contentTypeMatches(detected string, wanted string) bool {
+
+	wanted = strings.TrimSpace(wanted)
+
+	if strings.HasSuffix(wanted, "/*") {
+		prefix := strings.TrimSuffix(wanted, "*")
+		return len(detected) >= len(prefix) &&
+			strings.EqualFold(detected[:len(prefix)], prefix)
+	}
+
+	return strings.EqualFold(detected, wanted)
+}
+
+// ReadContentRange reads at most limit bytes from the specified file. If
+// fromEnd is true the last limit bytes of the file are returned, otherwise
+// the first limit bytes are returned. Only regular files are read; the file
+// is opened without blocking so that other files (e.g., FIFOs) cannot stall
+// the application.
+func ReadContentRange(path string, limit int, fromEnd bool) ([]byte, error) {
+
+	fh, err := os.OpenFile(filepath.Clean(path), os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// Read-only file handle; errors on close are not actionable here.
+		_ = fh.Close()
+	}()
+
+	fileInfo, err := fh.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if !fileInfo.Mode().IsRegular() {
+		return nil, fmt.Errorf("unable to read %s: not a regular file", path)
+	}
+
+	if fromEnd {
+		offset := fileInfo.Size() - int64(limit)
+		if offset > 0 {
+			if _, err := fh.Seek(offset, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("unable to seek to offset %d: %w", offset, err)
+			}
+		}
+	}
+
+	return io.ReadAll(io.LimitReader(fh, int64(limit)))
+}
+
 // InList is a helper function to emulate Python's `if "x" in list:`
 // functionality. The caller can optionally ignore case of compared items.
 func InList(needle string, haystack []string, ignoreCase bool) bool {
@@ -264,6 +615,21 @@ func InList(needle string, haystack []string, ignoreCase bool) bool {
 	return false
 }
 
+// Newest returns the most recently modified file in the slice. The zero
+// value is returned if the slice is empty.
+func (fm FileMatches) Newest() FileMatch {
+
+	var newest FileMatch
+
+	for _, file := range fm {
+		if newest.FileInfo == nil || file.ModTime().After(newest.ModTime()) {
+			newest = file
+		}
+	}
+
+	return newest
+}
+
 // SortByModTimeAsc sorts slice of FileMatch objects in ascending order with
 // older values listed first.
 func (fm FileMatches) SortByModTimeAsc() {
@@ -282,7 +648,8 @@ func (fm FileMatches) SortByModTimeDesc() {
 
 // FilesToPrune receives a slice of FileMatch objects and a config object.
 // Returns a slice of FileMatch objects selected based on the current config
-// object settings.
+// object settings. Protected files count toward the number of files to keep,
+// but are excluded from the returned slice.
 func (fm FileMatches) FilesToPrune(c *config.Config) FileMatches {
 
 	log := c.GetLogger()
@@ -313,5 +680,19 @@ func (fm FileMatches) FilesToPrune(c *config.Config) FileMatches {
 		"num_to_keep": c.GetNumFilesToKeep(),
 	}).Debug("Building list of files to prune by skipping forward specified number of files to keep")
 
-	return fm[pruneStartRange:pruneEndRange]
+	pruneCandidates := fm[pruneStartRange:pruneEndRange]
+	filesToPrune := make(FileMatches, 0, len(pruneCandidates))
+
+	for _, file := range pruneCandidates {
+		if file.Protected() {
+			log.WithFields(logrus.Fields{
+				"file":             file.Path,
+				"protected_reason": file.ProtectedReason,
+			}).Debug("Skipping protected file")
+			continue
+		}
+		filesToPrune = append(filesToPrune, file)
+	}
+
+	return filesToPrune
 }
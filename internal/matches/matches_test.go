@@ -0,0 +1,514 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matches
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+)
+
+// newTestConfig returns a minimal configuration that evaluates file age as
+// of the specified time using the specified time zone.
+func newTestConfig(t *testing.T, fileAge int, asOf string, timezone string, calendar bool) *config.Config {
+	t.Helper()
+
+	c := config.NewDefaultConfig()
+	*c.FileAge = fileAge
+	*c.AsOf = asOf
+	*c.Timezone = timezone
+	*c.CalendarAge = calendar
+	c.GetLogger().SetOutput(io.Discard)
+
+	return &c
+}
+
+func TestNewFileAgeThresholdAsOf(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		fileAge  int
+		asOf     string
+		timezone string
+		calendar bool
+		want     string
+	}{
+		{
+			name:     "rolling threshold early in the day",
+			fileAge:  1,
+			asOf:     "2026-03-10T00:05:00Z",
+			timezone: "UTC",
+			want:     "2026-03-09T00:05:00Z",
+		},
+		{
+			name:     "rolling threshold late in the day",
+			fileAge:  1,
+			asOf:     "2026-03-10T23:55:00Z",
+			timezone: "UTC",
+			want:     "2026-03-09T23:55:00Z",
+		},
+		{
+			name:     "calendar threshold early in the day",
+			fileAge:  1,
+			asOf:     "2026-03-10T00:05:00Z",
+			timezone: "UTC",
+			calendar: true,
+			want:     "2026-03-09T00:00:00Z",
+		},
+		{
+			name:     "calendar threshold late in the day",
+			fileAge:  1,
+			asOf:     "2026-03-10T23:55:00Z",
+			timezone: "UTC",
+			calendar: true,
+			want:     "2026-03-09T00:00:00Z",
+		},
+		{
+			name:     "calendar threshold in configured time zone",
+			fileAge:  1,
+			asOf:     "2026-03-10T02:00:00Z",
+			timezone: "America/Chicago",
+			calendar: true,
+			want:     "2026-03-08T00:00:00-06:00",
+		},
+		{
+			name:     "calendar threshold across daylight saving time change",
+			fileAge:  2,
+			asOf:     "2026-03-09T12:00:00-05:00",
+			timezone: "America/Chicago",
+			calendar: true,
+			want:     "2026-03-07T00:00:00-06:00",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestConfig(t, tt.fileAge, tt.asOf, tt.timezone, tt.calendar)
+
+			threshold := NewFileAgeThreshold(c.GetFileAge(), c)
+			if got := threshold.FormatLog(); got != tt.want {
+				t.Errorf("got threshold %s, want %s", got, tt.want)
+			}
+
+			if threshold.Time().Location().String() != tt.timezone {
+				t.Errorf("got location %s, want %s",
+					threshold.Time().Location(), tt.timezone)
+			}
+		})
+	}
+}
+
+func TestHasMatchingAgeAsOf(t *testing.T) {
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "test.tmp")
+	if err := os.WriteFile(file, []byte("test"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	modTime := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	fileInfo, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		fileAge  int
+		asOf     string
+		calendar bool
+		want     bool
+	}{
+		{
+			name:    "file newer than threshold",
+			fileAge: 3,
+			asOf:    "2026-03-04T11:59:59Z",
+			want:    false,
+		},
+		{
+			name:    "file equal to threshold",
+			fileAge: 3,
+			asOf:    "2026-03-04T12:00:00Z",
+			want:    true,
+		},
+		{
+			name:    "file older than threshold",
+			fileAge: 3,
+			asOf:    "2026-03-05T00:00:00Z",
+			want:    true,
+		},
+		{
+			name:     "file from the threshold day in calendar mode",
+			fileAge:  3,
+			asOf:     "2026-03-04T23:59:59Z",
+			calendar: true,
+			want:     false,
+		},
+		{
+			name:     "file from before the threshold day in calendar mode",
+			fileAge:  3,
+			asOf:     "2026-03-05T00:00:00Z",
+			calendar: true,
+			want:     true,
+		},
+		{
+			name:    "age check disabled",
+			fileAge: 0,
+			asOf:    "2026-03-01T00:00:00Z",
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestConfig(t, tt.fileAge, tt.asOf, "UTC", tt.calendar)
+
+			if got := HasMatchingAge(fileInfo, c); got != tt.want {
+				t.Errorf("HasMatchingAge() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasMatchingFilenamePatternNormalization(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		filename      string
+		pattern       string
+		normalization string
+		caseFold      bool
+		want          bool
+	}{
+		{
+			name:     "NFD filename and NFC pattern without normalization",
+			filename: "re\u0301sume\u0301-2020.tmp",
+			pattern:  "r\u00e9sum\u00e9-",
+			want:     false,
+		},
+		{
+			name:          "NFD filename and NFC pattern with NFC normalization",
+			filename:      "re\u0301sume\u0301-2020.tmp",
+			pattern:       "r\u00e9sum\u00e9-",
+			normalization: config.NormalizationNFC,
+			want:          true,
+		},
+		{
+			name:          "NFC filename and NFD pattern with NFD normalization",
+			filename:      "r\u00e9sum\u00e9-2020.tmp",
+			pattern:       "re\u0301sume\u0301-",
+			normalization: config.NormalizationNFD,
+			want:          true,
+		},
+		{
+			name:          "compatibility equivalent pattern with NFKC normalization",
+			filename:      "report\u2460.tmp",
+			pattern:       "report1",
+			normalization: config.NormalizationNFKC,
+			want:          true,
+		},
+		{
+			name:     "mixed case pattern without case folding",
+			filename: "STRASSE-2020.tmp",
+			pattern:  "stra\u00dfe",
+			want:     false,
+		},
+		{
+			name:     "mixed case pattern with case folding",
+			filename: "STRASSE-2020.tmp",
+			pattern:  "stra\u00dfe",
+			caseFold: true,
+			want:     true,
+		},
+		{
+			name:          "invalid UTF-8 filename",
+			filename:      "report-\xff.tmp",
+			pattern:       "report-",
+			normalization: config.NormalizationNFC,
+			caseFold:      true,
+			want:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.NewDefaultConfig()
+			*c.FilePattern = tt.pattern
+			*c.Normalization = tt.normalization
+			*c.CaseFold = tt.caseFold
+			c.GetLogger().SetOutput(io.Discard)
+
+			if got := HasMatchingFilenamePattern(tt.filename, &c); got != tt.want {
+				t.Errorf("HasMatchingFilenamePattern() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasMatchingExtensionNormalization(t *testing.T) {
+
+	c := config.NewDefaultConfig()
+	c.FileExtensions = []string{".caf\u00e9"}
+	c.GetLogger().SetOutput(io.Discard)
+
+	filename := "backup.cafe\u0301"
+
+	if HasMatchingExtension(filename, &c) {
+		t.Errorf("HasMatchingExtension() = true without normalization, want false")
+	}
+
+	*c.Normalization = config.NormalizationNFC
+	if !HasMatchingExtension(filename, &c) {
+		t.Errorf("HasMatchingExtension() = false with normalization, want true")
+	}
+}
+
+func TestReadContentRange(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("0123456789"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		limit   int
+		fromEnd bool
+		want    string
+	}{
+		{name: "prefix", limit: 4, want: "0123"},
+		{name: "suffix", limit: 4, fromEnd: true, want: "6789"},
+		{name: "prefix longer than file", limit: 64, want: "0123456789"},
+		{name: "suffix longer than file", limit: 64, fromEnd: true, want: "0123456789"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadContentRange(path, tt.limit, tt.fromEnd)
+			if err != nil {
+				t.Fatalf("ReadContentRange() failed: %s", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("ReadContentRange() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasMatchingContent(t *testing.T) {
+
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "app.log")
+	content := "HEADER 2020-01-01\n" + strings.Repeat("INFO log line\n", 64) + "FOOTER exit 0\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		pattern string
+		regex   string
+		bytes   int
+		fromEnd bool
+		want    bool
+	}{
+		{name: "no content criteria", path: path, want: true},
+		{name: "literal in prefix", path: path, pattern: "HEADER", bytes: 32, want: true},
+		{name: "literal outside prefix", path: path, pattern: "FOOTER", bytes: 32, want: false},
+		{name: "literal in suffix", path: path, pattern: "FOOTER", bytes: 32, fromEnd: true, want: true},
+		{name: "literal outside suffix", path: path, pattern: "HEADER", bytes: 32, fromEnd: true, want: false},
+		{name: "regex in prefix", path: path, regex: `^HEADER \d{4}-`, bytes: 32, want: true},
+		{name: "regex in suffix", path: path, regex: `exit \d+\n$`, bytes: 32, fromEnd: true, want: true},
+		{name: "regex not matched", path: path, regex: `ERROR|FATAL`, bytes: 32, want: false},
+		{name: "literal and regex both matched", path: path, pattern: "HEADER", regex: `\d{4}`, bytes: 32, want: true},
+		{name: "literal matched but regex not", path: path, pattern: "HEADER", regex: `ERROR`, bytes: 32, want: false},
+		{name: "file shorter than limit", path: path, pattern: "FOOTER", bytes: 1 << 20, want: true},
+		{name: "missing file", path: filepath.Join(dir, "missing.log"), pattern: "HEADER", bytes: 32, want: false},
+		{name: "directory", path: dir, pattern: "HEADER", bytes: 32, want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.NewDefaultConfig()
+			*c.ContentPattern = tt.pattern
+			*c.ContentRegex = tt.regex
+			*c.ContentBytes = tt.bytes
+			*c.ContentFromEnd = tt.fromEnd
+			c.GetLogger().SetOutput(io.Discard)
+
+			if got := HasMatchingContent(tt.path, &c); got != tt.want {
+				t.Errorf("HasMatchingContent() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentTypeMatches(t *testing.T) {
+
+	tests := []struct {
+		detected string
+		wanted   string
+		want     bool
+	}{
+		{detected: "text/plain", wanted: "text/plain", want: true},
+		{detected: "text/plain", wanted: "TEXT/Plain", want: true},
+		{detected: "text/plain", wanted: " text/plain ", want: true},
+		{detected: "text/plain", wanted: "text/html", want: false},
+		{detected: "text/plain", wanted: "text/*", want: true},
+		{detected: "text/html", wanted: "Text/*", want: true},
+		{detected: "application/zip", wanted: "text/*", want: false},
+		{detected: "text", wanted: "text/*", want: false},
+		{detected: "textual/plain", wanted: "text/*", want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.detected+" "+tt.wanted, func(t *testing.T) {
+			if got := contentTypeMatches(tt.detected, tt.wanted); got != tt.want {
+				t.Errorf("contentTypeMatches(%q, %q) = %t, want %t", tt.detected, tt.wanted, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasMatchingContentType(t *testing.T) {
+
+	dir := t.TempDir()
+
+	files := map[string][]byte{
+		"app.log":  []byte("2020-01-01 00:00:00 INFO log line\n"),
+		"logs.zip": {0x50, 0x4b, 0x03, 0x04, 0x14, 0x00, 0x00, 0x00},
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		file        string
+		types       []string
+		wantType    string
+		wantMatched bool
+	}{
+		{name: "no content types", file: "app.log", wantMatched: true},
+
+		// The detected type is "text/plain; charset=utf-8"; the parameter
+		// is not part of the type compared.
+		{name: "parameter stripped", file: "app.log", types: []string{"text/plain"}, wantType: "text/plain", wantMatched: true},
+		{name: "wildcard subtype", file: "app.log", types: []string{"text/*"}, wantType: "text/plain", wantMatched: true},
+		{name: "case insensitive", file: "logs.zip", types: []string{"Application/ZIP"}, wantType: "application/zip", wantMatched: true},
+		{name: "any of several types", file: "logs.zip", types: []string{"text/*", "application/zip"}, wantType: "application/zip", wantMatched: true},
+		{name: "not matched", file: "logs.zip", types: []string{"text/*"}, wantType: "application/zip", wantMatched: false},
+		{name: "missing file", file: "missing.log", types: []string{"text/*"}, wantMatched: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.NewDefaultConfig()
+			c.ContentTypes = tt.types
+			c.GetLogger().SetOutput(io.Discard)
+
+			gotType, gotMatched := HasMatchingContentType(filepath.Join(dir, tt.file), &c)
+			if gotType != tt.wantType || gotMatched != tt.wantMatched {
+				t.Errorf("HasMatchingContentType() = %q, %t, want %q, %t",
+					gotType, gotMatched, tt.wantType, tt.wantMatched)
+			}
+		})
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+
+	if got := DisplayName("r\u00e9sum\u00e9.tmp"); got != "r\u00e9sum\u00e9.tmp" {
+		t.Errorf("DisplayName() = %s, want name returned as-is", got)
+	}
+
+	if got, want := DisplayName("report-\xff.tmp"), `"report-\xff.tmp"`; got != want {
+		t.Errorf("DisplayName() = %s, want %s", got, want)
+	}
+}
+
+func TestStabilityStateSave(t *testing.T) {
+
+	dir := t.TempDir()
+	stateFile := filepath.Join(dir, "state.json")
+
+	var files FileMatches
+	for _, name := range []string{"a.log", "b.log"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("stability test\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, FileMatch{FileInfo: info, Path: path})
+	}
+
+	state, err := LoadStabilityState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.Record(files)
+	if err := state.Save(stateFile); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next run only matches one of the files, so the other file is
+	// dropped from the saved state.
+	state, err = LoadStabilityState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Files) != 2 {
+		t.Fatalf("got %d recorded files, want 2", len(state.Files))
+	}
+	state.Record(files[:1])
+	if err := state.Save(stateFile); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = LoadStabilityState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state.Files[files[0].Path]; len(state.Files) != 1 || !ok {
+		t.Errorf("got recorded files %v, want only %s", state.Files, files[0].Path)
+	}
+}
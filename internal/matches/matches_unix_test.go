@@ -0,0 +1,56 @@
+//go:build !windows
+// +build !windows
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matches
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"golang.org/x/sys/unix"
+)
+
+func TestHasMatchingContentFIFO(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := unix.Mkfifo(path, 0600); err != nil {
+		t.Skipf("unable to create FIFO: %s", err)
+	}
+
+	c := config.NewDefaultConfig()
+	*c.ContentPattern = "ERROR"
+	c.GetLogger().SetOutput(io.Discard)
+
+	result := make(chan bool, 1)
+	go func() {
+		result <- HasMatchingContent(path, &c)
+	}()
+
+	select {
+	case got := <-result:
+		if got {
+			t.Error("HasMatchingContent() = true for FIFO, want false")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("HasMatchingContent() blocked on FIFO")
+	}
+}
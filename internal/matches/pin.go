@@ -0,0 +1,96 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matches
+
+import (
+	"strings"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// PinValueIndefinite is the pin attribute value used to pin a file with no
+// expiration.
+const PinValueIndefinite = "1"
+
+// IsPinned indicates whether the specified file has been pinned against
+// removal via the configured extended attribute. A file is pinned if the
+// attribute holds an RFC 3339 timestamp that is still in the future
+// (relative to the configured reference time) or any other non-empty value.
+// Files are considered unpinned if the attribute is absent or extended
+// attributes are not supported. Files whose attribute cannot be read for any
+// other reason are considered pinned.
+func IsPinned(path string, config *config.Config) bool {
+
+	log := config.GetLogger()
+
+	contextLogger := log.WithFields(logrus.Fields{
+		"filename":      path,
+		"pin_attribute": config.GetPinAttribute(),
+	})
+
+	value, found, err := getXattr(path, config.GetPinAttribute())
+	if err != nil {
+		contextLogger.Warnf("IsPinned: unable to read pin attribute, treating file as pinned: %s", err)
+		return true
+	}
+
+	if !found {
+		return false
+	}
+
+	value = strings.TrimSpace(value)
+	contextLogger = contextLogger.WithField("pin_value", value)
+
+	if value == "" {
+		contextLogger.Debug("IsPinned: empty pin attribute, ignoring")
+		return false
+	}
+
+	expires, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		contextLogger.Debug("IsPinned: file pinned indefinitely")
+		return true
+	}
+
+	if config.Now().After(expires) {
+		contextLogger.Debug("IsPinned: pin has expired")
+		return false
+	}
+
+	contextLogger.Debug("IsPinned: file pinned until expiration")
+	return true
+}
+
+// Pin sets the specified extended attribute on a file to protect it from
+// removal. If until is the zero value the file is pinned indefinitely.
+func Pin(path string, attribute string, until time.Time) error {
+
+	value := PinValueIndefinite
+	if !until.IsZero() {
+		value = until.Format(time.RFC3339)
+	}
+
+	return setXattr(path, attribute, value)
+}
+
+// Unpin removes the specified extended attribute from a file. Removing an
+// attribute that is not set is not considered an error.
+func Unpin(path string, attribute string) error {
+	return removeXattr(path, attribute)
+}
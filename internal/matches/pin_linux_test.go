@@ -0,0 +1,153 @@
+//go:build linux
+// +build linux
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matches
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"golang.org/x/sys/unix"
+)
+
+// pinOrSkip pins the file until the specified time, skipping the test if the
+// filesystem does not support extended attributes.
+func pinOrSkip(t *testing.T, path string, attribute string, until time.Time) {
+	t.Helper()
+
+	err := Pin(path, attribute, until)
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		t.Skipf("extended attributes not supported: %s", err)
+	}
+	if err != nil {
+		t.Fatalf("Pin() failed: %s", err)
+	}
+}
+
+func TestPinUnpin(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("pin test\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := config.NewDefaultConfig()
+	c.GetLogger().SetOutput(io.Discard)
+
+	if IsPinned(path, &c) {
+		t.Fatal("IsPinned() = true before pinning, want false")
+	}
+
+	pinOrSkip(t, path, c.GetPinAttribute(), time.Time{})
+
+	value, found, err := getXattr(path, c.GetPinAttribute())
+	if err != nil || !found || value != PinValueIndefinite {
+		t.Errorf("got pin attribute %q (found: %t, error: %v), want %q", value, found, err, PinValueIndefinite)
+	}
+
+	if !IsPinned(path, &c) {
+		t.Error("IsPinned() = false after pinning, want true")
+	}
+
+	if err := Unpin(path, c.GetPinAttribute()); err != nil {
+		t.Fatalf("Unpin() failed: %s", err)
+	}
+
+	if IsPinned(path, &c) {
+		t.Error("IsPinned() = true after unpinning, want false")
+	}
+
+	// Unpinning a file which is not pinned is not an error.
+	if err := Unpin(path, c.GetPinAttribute()); err != nil {
+		t.Errorf("Unpin() failed for file which is not pinned: %s", err)
+	}
+
+	until := time.Date(2026, time.January, 2, 15, 4, 5, 0, time.UTC)
+	pinOrSkip(t, path, c.GetPinAttribute(), until)
+
+	value, _, err = getXattr(path, c.GetPinAttribute())
+	if err != nil || value != until.Format(time.RFC3339) {
+		t.Errorf("got pin attribute %q (error: %v), want %q", value, err, until.Format(time.RFC3339))
+	}
+}
+
+func TestIsPinnedExpiry(t *testing.T) {
+
+	until := time.Date(2026, time.January, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		asOf  string
+		want  bool
+	}{
+		{name: "before expiration", value: until.Format(time.RFC3339), asOf: "2026-01-01T00:00:00Z", want: true},
+		{name: "after expiration", value: until.Format(time.RFC3339), asOf: "2026-01-03T00:00:00Z", want: false},
+		{name: "expiration in other time zone", value: "2026-01-02T10:04:05-05:00", asOf: "2026-01-02T15:00:00Z", want: true},
+		{name: "indefinite", value: PinValueIndefinite, asOf: "2030-01-01T00:00:00Z", want: true},
+		{name: "other value", value: "keep", want: true},
+		{name: "empty value", value: " ", want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			if err := os.WriteFile(path, []byte("pin test\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			c := config.NewDefaultConfig()
+			*c.AsOf = tt.asOf
+			c.GetLogger().SetOutput(io.Discard)
+
+			pinOrSkip(t, path, c.GetPinAttribute(), time.Time{})
+			if err := setXattr(path, c.GetPinAttribute(), tt.value); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := IsPinned(path, &c); got != tt.want {
+				t.Errorf("IsPinned() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPinnedUnreadable(t *testing.T) {
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(file, []byte("pin test\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := config.NewDefaultConfig()
+	c.GetLogger().SetOutput(io.Discard)
+
+	// Reading the attribute fails with ENOTDIR as the file is not a
+	// directory.
+	if !IsPinned(filepath.Join(file, "child.log"), &c) {
+		t.Error("IsPinned() = false for unreadable pin attribute, want true")
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matches
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Reasons recorded for files skipped by the write-stability check.
+const (
+
+	// SkipReasonChanged indicates that the size or modification time of the
+	// file changed during the settle interval.
+	SkipReasonChanged string = "size or modification time changed during settle interval"
+
+	// SkipReasonChangedSinceLastRun indicates that the size or modification
+	// time of the file differs from the value recorded by the previous run.
+	SkipReasonChangedSinceLastRun string = "size or modification time changed since previous run"
+
+	// SkipReasonNotPreviouslySeen indicates that the file was not recorded
+	// by the previous run and so its stability cannot be confirmed.
+	SkipReasonNotPreviouslySeen string = "not recorded by previous run"
+
+	// SkipReasonUnavailable indicates that the file could not be re-checked.
+	SkipReasonUnavailable string = "unable to re-check file"
+)
+
+// FileState represents the size and modification time recorded for a file.
+type FileState struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// StabilityState represents the size and modification time of previously
+// matched files, indexed by path. This is persisted between runs so that
+// files which are still being written can be detected without waiting for a
+// settle interval.
+type StabilityState struct {
+	Files map[string]FileState `json:"files"`
+
+	// State of files matched by the current run, written by Save in place
+	// of the previously recorded state.
+	current map[string]FileState
+}
+
+// LoadStabilityState reads previously recorded file state from the specified
+// file. An empty state is returned if the file does not exist yet.
+func LoadStabilityState(path string) (StabilityState, error) {
+
+	state := StabilityState{
+		Files:   make(map[string]FileState),
+		current: make(map[string]FileState),
+	}
+
+	content, err := os.ReadFile(filepath.Clean(path))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return state, nil
+	case err != nil:
+		return state, fmt.Errorf("unable to read stability state file: %w", err)
+	}
+
+	if err := json.Unmarshal(content, &state); err != nil {
+		return state, fmt.Errorf("unable to parse stability state file: %w", err)
+	}
+
+	if state.Files == nil {
+		state.Files = make(map[string]FileState)
+	}
+
+	return state, nil
+}
+
+// Record records the current size and modification time of each provided
+// file for the next run. The previously recorded state used to check files
+// is left unchanged.
+func (s StabilityState) Record(files FileMatches) {
+	for _, file := range files {
+		s.current[file.Path] = FileState{
+			Size:    file.Size(),
+			ModTime: file.ModTime(),
+		}
+	}
+}
+
+// Save writes the state of the files recorded by the current run to the
+// specified file, so that entries for files which were removed or have
+// disappeared since the previous run are dropped. The state is written to a
+// temporary file first and then renamed into place so that an interrupted
+// write does not leave a truncated state file behind.
+func (s StabilityState) Save(path string) error {
+
+	content, err := json.MarshalIndent(StabilityState{Files: s.current}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode stability state: %w", err)
+	}
+
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, content, 0600); err != nil {
+		return fmt.Errorf("unable to write stability state file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, path); err != nil {
+		return fmt.Errorf("unable to replace stability state file: %w", err)
+	}
+
+	return nil
+}
+
+// CheckStability confirms that files have not changed since they were
+// matched. If a settle interval is configured, the size and modification
+// time of each file are re-checked once the interval has passed. If a
+// stability state from a previous run is provided, each file is compared
+// against the recorded values. Files that changed are returned separately
+// with the reason recorded in the SkipReason field.
+func (fm FileMatches) CheckStability(c *config.Config, previous *StabilityState) (FileMatches, FileMatches) {
+
+	log := c.GetLogger()
+
+	settleInterval := time.Duration(c.GetSettleSeconds()) * time.Second
+
+	if settleInterval > 0 {
+		log.WithFields(logrus.Fields{
+			"settle_seconds": c.GetSettleSeconds(),
+			"files":          len(fm),
+		}).Info("Waiting for settle interval before re-checking files")
+		time.Sleep(settleInterval)
+	}
+
+	stable := make(FileMatches, 0, len(fm))
+	var unstable FileMatches
+
+	for _, file := range fm {
+
+		if settleInterval > 0 {
+			current, err := os.Lstat(file.Path)
+			switch {
+			case err != nil:
+				file.SkipReason = SkipReasonUnavailable
+			case current.Size() != file.Size() || !current.ModTime().Equal(file.ModTime()):
+				file.SkipReason = SkipReasonChanged
+			}
+		}
+
+		if file.SkipReason == "" && previous != nil {
+			recorded, ok := previous.Files[file.Path]
+			switch {
+			case !ok:
+				file.SkipReason = SkipReasonNotPreviouslySeen
+			case recorded.Size != file.Size() || !recorded.ModTime.Equal(file.ModTime()):
+				file.SkipReason = SkipReasonChangedSinceLastRun
+			}
+		}
+
+		if file.SkipReason != "" {
+			log.WithFields(logrus.Fields{
+				"file":        file.Path,
+				"skip_reason": file.SkipReason,
+			}).Debug("File failed write-stability check")
+			unstable = append(unstable, file)
+			continue
+		}
+
+		stable = append(stable, file)
+	}
+
+	return stable, unstable
+}
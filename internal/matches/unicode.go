@@ -0,0 +1,64 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matches
+
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/atc0005/elbow/internal/config"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeName applies the configured case folding and Unicode
+// normalization form to the specified name. This is applied to filename
+// patterns, extensions and filenames alike so that names which are
+// canonically equivalent (e.g., NFD names created by macOS clients and NFC
+// patterns) compare as equal. The name is returned unmodified if neither
+// setting is enabled.
+func NormalizeName(name string, c *config.Config) string {
+
+	// Case folding may produce output that is no longer in the requested
+	// normalization form, so fold first and normalize the result.
+	if c.GetCaseFold() {
+		name = cases.Fold().String(name)
+	}
+
+	switch c.GetUnicodeNormalization() {
+	case config.NormalizationNFC:
+		name = norm.NFC.String(name)
+	case config.NormalizationNFD:
+		name = norm.NFD.String(name)
+	case config.NormalizationNFKC:
+		name = norm.NFKC.String(name)
+	}
+
+	return name
+}
+
+// DisplayName returns a representation of the specified name that is safe
+// for use in log messages. Names that are not valid UTF-8 are returned as a
+// quoted string with invalid bytes escaped; all other names are returned
+// as-is.
+func DisplayName(name string) string {
+	if utf8.ValidString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
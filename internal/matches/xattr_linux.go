@@ -0,0 +1,65 @@
+//go:build linux
+// +build linux
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matches
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// getXattr retrieves the value of the specified extended attribute. The
+// found return value is false if the attribute is not set or if the
+// filesystem does not support extended attributes; any other failure to read
+// the attribute is returned as an error.
+func getXattr(path string, name string) (string, bool, error) {
+
+	size, err := unix.Getxattr(path, name, nil)
+	switch {
+	case errors.Is(err, unix.ENODATA), errors.Is(err, unix.ENOTSUP), errors.Is(err, unix.EOPNOTSUPP):
+		return "", false, nil
+	case err != nil:
+		return "", false, err
+	}
+
+	buf := make([]byte, size)
+	size, err = unix.Getxattr(path, name, buf)
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(buf[:size]), true, nil
+}
+
+// setXattr sets the specified extended attribute to the given value,
+// replacing any existing value.
+func setXattr(path string, name string, value string) error {
+	return unix.Setxattr(path, name, []byte(value), 0)
+}
+
+// removeXattr removes the specified extended attribute. Removing an
+// attribute that is not set is not considered an error.
+func removeXattr(path string, name string) error {
+	err := unix.Removexattr(path, name)
+	if errors.Is(err, unix.ENODATA) {
+		return nil
+	}
+	return err
+}
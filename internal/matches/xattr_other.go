@@ -0,0 +1,44 @@
+//go:build !linux
+// +build !linux
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matches
+
+import (
+	"errors"
+)
+
+// errXattrUnsupported indicates that extended attributes are not supported
+// on the current platform.
+var errXattrUnsupported = errors.New("extended attributes are not supported on this platform")
+
+// getXattr is a no-op on platforms without extended attribute support. Files
+// are reported as not having the attribute set.
+func getXattr(_ string, _ string) (string, bool, error) {
+	return "", false, nil
+}
+
+// setXattr is not supported on this platform.
+func setXattr(_ string, _ string, _ string) error {
+	return errXattrUnsupported
+}
+
+// removeXattr is not supported on this platform.
+func removeXattr(_ string, _ string) error {
+	return errXattrUnsupported
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/units"
+)
+
+// ErrGuardTripped indicates that the files planned for removal from a path
+// exceed one of the configured safety limits.
+var ErrGuardTripped = errors.New("safety limit exceeded")
+
+// CheckGuards verifies that the files planned for removal from a path stay
+// within the configured safety limits. The planned files are evaluated
+// against all matching files for the path and the total number of files
+// found below the path. An error wrapping ErrGuardTripped and describing
+// the first exceeded limit is returned, nil otherwise.
+func CheckGuards(config *config.Config, planned matches.FileMatches, matched matches.FileMatches, total int) error {
+
+	if limit := config.GetMaxRemovals(); limit > 0 && len(planned) > limit {
+		return fmt.Errorf(
+			"%w: %d files planned for removal (limit %d)",
+			ErrGuardTripped,
+			len(planned),
+			limit,
+		)
+	}
+
+	if limit := int64(config.GetMaxRemovalSize()); limit > 0 && planned.TotalFileSize() > limit {
+		return fmt.Errorf(
+			"%w: %s planned for removal (limit %s)",
+			ErrGuardTripped,
+			planned.TotalFileSizeHR(),
+			units.ByteCountIEC(limit),
+		)
+	}
+
+	if limit := config.GetMaxRemovalPercent(); limit > 0 && total > 0 && len(planned)*100 > limit*total {
+		return fmt.Errorf(
+			"%w: %d of %d files (%.1f%%) planned for removal (limit %d%%)",
+			ErrGuardTripped,
+			len(planned),
+			total,
+			float64(len(planned))*100/float64(total),
+			limit,
+		)
+	}
+
+	if config.GetKeepLastMatch() && len(planned) > 0 && len(planned) >= len(matched) {
+		return fmt.Errorf(
+			"%w: all %d matching files planned for removal",
+			ErrGuardTripped,
+			len(matched),
+		)
+	}
+
+	return nil
+}
+
+// CheckRunGuards evaluates the files planned for removal from each of the
+// specified paths against the configured safety limits, so that a run can be
+// aborted before any path is pruned. Paths which cannot be evaluated are
+// left for the regular processing of the path to report. As files skipped by
+// the write-stability check are still counted, the evaluation errs on the
+// side of caution. The first path exceeding a limit is returned along with
+// an error wrapping ErrGuardTripped, nil otherwise.
+func CheckRunGuards(config *config.Config, searchPaths []string) (string, error) {
+
+	for _, path := range searchPaths {
+
+		pathConfig := config.ForPath(path)
+
+		fileMatches, total, err := ProcessPath(pathConfig, path)
+		if err != nil || len(fileMatches) == 0 {
+			continue
+		}
+
+		if pathConfig.GetProtectSymlinkTargets() {
+			if _, err := ProtectSymlinkTargets(pathConfig, path, fileMatches); err != nil {
+				continue
+			}
+		}
+
+		planned := fileMatches.FilesToPrune(pathConfig)
+
+		if err := CheckGuards(pathConfig, planned, fileMatches, total); err != nil {
+			return path, err
+		}
+	}
+
+	return "", nil
+}
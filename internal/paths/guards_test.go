@@ -0,0 +1,140 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/atc0005/elbow/internal/config"
+)
+
+func TestCheckGuards(t *testing.T) {
+
+	files := newTestFiles(t, 10)
+	size := files[0].Size()
+
+	tests := []struct {
+		name      string
+		configure func(c *config.Config)
+		planned   int
+		total     int
+		wantTrip  bool
+	}{
+		{
+			name:      "no limits",
+			configure: func(c *config.Config) {},
+			planned:   10,
+			total:     10,
+		},
+		{
+			name:      "count within limit",
+			configure: func(c *config.Config) { *c.MaxRemovals = 5 },
+			planned:   5,
+			total:     100,
+		},
+		{
+			name:      "count exceeded",
+			configure: func(c *config.Config) { *c.MaxRemovals = 5 },
+			planned:   6,
+			total:     100,
+			wantTrip:  true,
+		},
+		{
+			name:      "size exceeded",
+			configure: func(c *config.Config) { *c.MaxRemovalSize = int(size) * 2 },
+			planned:   3,
+			total:     100,
+			wantTrip:  true,
+		},
+		{
+			name:      "percentage within limit",
+			configure: func(c *config.Config) { *c.MaxRemovalPct = 50 },
+			planned:   5,
+			total:     10,
+		},
+		{
+			name:      "percentage exceeded",
+			configure: func(c *config.Config) { *c.MaxRemovalPct = 50 },
+			planned:   6,
+			total:     10,
+			wantTrip:  true,
+		},
+		{
+			name:      "last match kept",
+			configure: func(c *config.Config) { *c.KeepLastMatch = true },
+			planned:   9,
+			total:     10,
+		},
+		{
+			name:      "last match planned for removal",
+			configure: func(c *config.Config) { *c.KeepLastMatch = true },
+			planned:   10,
+			total:     10,
+			wantTrip:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.NewDefaultConfig()
+			tt.configure(&c)
+
+			err := CheckGuards(&c, files[:tt.planned], files, tt.total)
+
+			switch {
+			case tt.wantTrip && !errors.Is(err, ErrGuardTripped):
+				t.Errorf("got error %v, want %v", err, ErrGuardTripped)
+			case !tt.wantTrip && err != nil:
+				t.Errorf("got error %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestCheckRunGuards(t *testing.T) {
+
+	// newTestFiles creates each set of files in a separate directory.
+	small := filepath.Dir(newTestFiles(t, 2)[0].Path)
+	large := filepath.Dir(newTestFiles(t, 6)[0].Path)
+
+	c := config.NewDefaultConfig()
+	c.GetLogger().SetOutput(io.Discard)
+	*c.MaxRemovals = 3
+
+	path, err := CheckRunGuards(&c, []string{small, large})
+	if !errors.Is(err, ErrGuardTripped) || path != large {
+		t.Errorf("got path %q and error %v, want %q and %v", path, err, large, ErrGuardTripped)
+	}
+
+	// Nothing is removed while evaluating the paths.
+	for _, dir := range []string{small, large} {
+		fileMatches, _, err := ProcessPath(&c, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fileMatches) == 0 {
+			t.Errorf("files removed from %s while evaluating safety limits", dir)
+		}
+	}
+
+	if path, err := CheckRunGuards(&c, []string{small}); err != nil {
+		t.Errorf("got path %q and error %v, want nil", path, err)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/atc0005/elbow/internal/actions"
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/journal"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/unlink"
+	"github.com/sirupsen/logrus"
+)
+
+// journalPath returns the absolute form of the specified path, as recorded
+// in the journal so that a run can be resumed from any working directory.
+func journalPath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return absPath
+}
+
+// RecordPlan records the files planned for removal below the specified path
+// (or for the specified lifecycle stage of the path) in the journal of the
+// run, if any.
+func RecordPlan(config *config.Config, path string, stage *int, files matches.FileMatches) error {
+
+	j := config.GetJournal()
+	if j == nil {
+		return nil
+	}
+
+	planned := make([]journal.File, 0, len(files))
+	for _, file := range files {
+		id := file.Identity
+		if id.IsZero() {
+			id = matches.NewIdentity(file.FileInfo)
+		}
+
+		planned = append(planned, journal.File{
+			Path:    journalPath(file.Path),
+			Device:  id.Device,
+			Inode:   id.Inode,
+			Size:    id.Size,
+			ModTime: id.ModTime,
+		})
+	}
+
+	return j.Plan(journalPath(path), stage, planned)
+}
+
+// PlannedFiles returns the files recorded in the journal of an interrupted
+// run which are still the same files found by that run, ready to be handled
+// as planned. Files which no longer exist or changed since they were found
+// are returned as skipped outcomes and recorded as such in the journal of
+// the run.
+func PlannedFiles(config *config.Config, files []journal.File) (matches.FileMatches, []actions.Outcome) {
+
+	var ready matches.FileMatches
+	var skipped []actions.Outcome
+
+	actionName := actions.New(config).Name()
+
+	for _, file := range files {
+
+		id := matches.Identity{
+			Device:  file.Device,
+			Inode:   file.Inode,
+			Size:    file.Size,
+			ModTime: file.ModTime,
+		}
+
+		info, err := os.Lstat(file.Path)
+		if err == nil {
+			err = unlink.Verify(config.GetSearchRoot(), file.Path, id)
+		}
+
+		if err != nil {
+			// The file may no longer exist, so report it as recorded.
+			info = plannedFileInfo{file: file}
+
+			outcome := actions.Outcome{
+				Action:     actionName,
+				File:       matches.FileMatch{FileInfo: info, Path: file.Path, Identity: id},
+				Status:     actions.Skipped,
+				SkipReason: fmt.Sprintf("unable to confirm file found by interrupted run: %s", err),
+			}
+			skipped = append(skipped, outcome)
+			config.GetJournal().Done(file.Path, outcome.Status.String())
+			continue
+		}
+
+		ready = append(ready, matches.FileMatch{FileInfo: info, Path: file.Path, Identity: id})
+	}
+
+	return ready, skipped
+}
+
+// plannedFileInfo describes a file as recorded in the journal, for files
+// which can no longer be examined.
+type plannedFileInfo struct {
+	file journal.File
+}
+
+func (fi plannedFileInfo) Name() string       { return filepath.Base(fi.file.Path) }
+func (fi plannedFileInfo) Size() int64        { return fi.file.Size }
+func (fi plannedFileInfo) Mode() os.FileMode  { return 0 }
+func (fi plannedFileInfo) ModTime() time.Time { return fi.file.ModTime }
+func (fi plannedFileInfo) IsDir() bool        { return false }
+func (fi plannedFileInfo) Sys() interface{}   { return nil }
+
+// ResumePlan handles the files of a plan recorded in the journal of an
+// interrupted run which were not handled by the run, after confirming that
+// each file is still the same file found by the run. Files which changed
+// are skipped.
+func ResumePlan(config *config.Config, files []journal.File) (PathPruningResults, error) {
+
+	ready, skipped := PlannedFiles(config, files)
+
+	for _, outcome := range skipped {
+		config.GetLogger().WithFields(logrus.Fields{
+			"file":        matches.DisplayName(outcome.File.Path),
+			"skip_reason": outcome.SkipReason,
+		}).Warn("Skipping file planned by interrupted run")
+	}
+
+	var results PathPruningResults
+	var err error
+
+	if len(ready) > 0 {
+		results, err = CleanPath(ready, config)
+	}
+
+	for _, outcome := range skipped {
+		results.record(outcome)
+	}
+
+	return results, err
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/atc0005/elbow/internal/archive"
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/journal"
+	"github.com/atc0005/elbow/internal/matches"
+)
+
+func TestResumePlanArchive(t *testing.T) {
+
+	baseDir := t.TempDir()
+	searchDir := filepath.Join(baseDir, "search")
+	archiveDir := filepath.Join(baseDir, "archive")
+
+	c := config.NewDefaultConfig()
+	c.GetLogger().SetOutput(io.Discard)
+	*c.ArchiveDir = archiveDir
+	*c.Remove = true
+
+	if err := os.Mkdir(searchDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	var files matches.FileMatches
+	var planned []journal.File
+	for _, name := range []string{"a.log", "b.log"} {
+		path := filepath.Join(searchDir, name)
+		if err := os.WriteFile(path, []byte("resume test\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		id := matches.NewIdentity(info)
+		files = append(files, matches.FileMatch{FileInfo: info, Path: path, Identity: id})
+		planned = append(planned, journal.File{
+			Path:    path,
+			Device:  id.Device,
+			Inode:   id.Inode,
+			Size:    id.Size,
+			ModTime: id.ModTime,
+		})
+	}
+
+	// The interrupted run created its archive, but removed no file.
+	if _, err := archive.Create(c.ForPath(searchDir), searchDir, files); err != nil {
+		t.Fatalf("archive.Create() failed: %s", err)
+	}
+
+	settings, err := c.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resuming := config.NewDefaultConfig()
+	resumeConfig, err := resuming.ForResume(c.GetRunID(), settings)
+	if err != nil {
+		t.Fatalf("ForResume() failed: %s", err)
+	}
+	resumeConfig.GetLogger().SetOutput(io.Discard)
+
+	results, err := ResumePlan(resumeConfig.ForPath(searchDir), planned)
+	if err != nil {
+		t.Fatalf("ResumePlan() failed: %s", err)
+	}
+
+	if len(results.FailedRemovals) != 0 {
+		t.Errorf("got %d failed removals, want 0", len(results.FailedRemovals))
+	}
+
+	for _, file := range files {
+		if _, err := os.Lstat(file.Path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s still present after resume", file.Path)
+		}
+	}
+
+	archives, err := filepath.Glob(filepath.Join(archiveDir, "*."+c.GetArchiveFormat()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(archives) != 2 {
+		t.Errorf("got archives %v, want one for the interrupted and one for the resuming run", archives)
+	}
+}
@@ -20,13 +20,21 @@
 package paths
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/atc0005/elbow/internal/actions"
 	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/journal"
 	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/throttle"
 	"github.com/sirupsen/logrus"
 )
 
@@ -44,6 +52,28 @@ type ProcessingResults struct {
 	// Number of files failed to remove.
 	FailedRemoved int
 
+	// Number of files protected from removal (e.g., pinned files).
+	Protected int
+
+	// Number of files skipped because they failed the write-stability
+	// check or did not need the configured action.
+	Skipped int
+
+	// Number of quarantine runs eligible for purging (purged if removal is
+	// enabled).
+	PurgedRuns int
+
+	// Number of paths not pruned because the pre hook vetoed pruning them.
+	VetoedPaths int
+
+	// Number of paths not pruned because the files planned for removal
+	// exceeded a safety limit.
+	GuardedPaths int
+
+	// Number of files modified in place (e.g., compressed or truncated)
+	// instead of being removed.
+	Modified int
+
 	// Size of all files eligible for removal.
 	EligibleFileSize int64
 
@@ -53,10 +83,126 @@ type ProcessingResults struct {
 	// Size of all files failed to remove.
 	FailedTotalFileSize int64
 
+	// Number of bytes saved by modifying files in place. Files modified in
+	// place are not included in the size of files successfully removed.
+	SavedFileSize int64
+
 	// Size of all files successfully and unsuccessfully removed. This is
 	// essentially the size of eligible files to be removed minus any files
 	// that are excluded by user request.
 	TotalProcessedFileSize int64
+
+	// Results for each action, in the order actions were first applied.
+	Actions []ActionResults
+
+	// Results for each lifecycle stage, in the order stages are configured.
+	Stages []StageResults
+}
+
+// Add records the results of processing a single path.
+func (pr *ProcessingResults) Add(results PathPruningResults) {
+
+	pr.SuccessRemoved += len(results.SuccessfulRemovals)
+	pr.SuccessTotalFileSize += results.SuccessfulRemovals.TotalFileSize()
+	pr.FailedRemoved += len(results.FailedRemovals)
+	pr.FailedTotalFileSize += results.FailedRemovals.TotalFileSize()
+	pr.Modified += len(results.Modified)
+	pr.Skipped += len(results.Skipped)
+	pr.SavedFileSize += results.SavedFileSize()
+
+	for _, outcome := range results.Outcomes {
+		pr.actionResults(outcome.Action).add(outcome)
+	}
+}
+
+// actionResults returns the results for the named action, adding them if
+// not yet present.
+func (pr *ProcessingResults) actionResults(action string) *ActionResults {
+
+	for i := range pr.Actions {
+		if pr.Actions[i].Action == action {
+			return &pr.Actions[i]
+		}
+	}
+
+	pr.Actions = append(pr.Actions, ActionResults{Action: action})
+
+	return &pr.Actions[len(pr.Actions)-1]
+}
+
+// ActionResults is used to collect execution results for a single action
+// across all paths.
+type ActionResults struct {
+
+	// Name of the action.
+	Action string
+
+	// Number of files the action was successfully applied to.
+	Succeeded int
+
+	// Number of files the action failed to apply to.
+	Failed int
+
+	// Number of files skipped by the action (e.g., already compressed).
+	Skipped int
+
+	// Size of all files the action was successfully applied to.
+	SucceededFileSize int64
+
+	// Number of bytes saved without removing files.
+	SavedFileSize int64
+}
+
+// add records the outcome of applying the action to a single file.
+func (ar *ActionResults) add(outcome actions.Outcome) {
+
+	switch outcome.Status {
+	case actions.Succeeded:
+		ar.Succeeded++
+		ar.SucceededFileSize += outcome.File.Size()
+		ar.SavedFileSize += outcome.Saved
+	case actions.Failed:
+		ar.Failed++
+	case actions.Skipped:
+		ar.Skipped++
+	}
+}
+
+// StageResults is used to collect execution results for a single lifecycle
+// stage across all paths.
+type StageResults struct {
+	Stage config.Stage
+
+	// Number of files successfully handled by the stage.
+	Processed int
+
+	// Number of files the stage failed to handle.
+	Failed int
+
+	// Number of files skipped by the stage (e.g., already compressed).
+	Skipped int
+
+	// Size of all files successfully handled by the stage.
+	ProcessedFileSize int64
+
+	// Number of bytes saved without removing files.
+	SavedFileSize int64
+}
+
+// Add records the results of applying the stage to the files of a single
+// path.
+func (sr *StageResults) Add(results PathPruningResults) {
+
+	var ar ActionResults
+	for _, outcome := range results.Outcomes {
+		ar.add(outcome)
+	}
+
+	sr.Processed += ar.Succeeded
+	sr.Failed += ar.Failed
+	sr.Skipped += ar.Skipped
+	sr.ProcessedFileSize += ar.SucceededFileSize
+	sr.SavedFileSize += ar.SavedFileSize
 }
 
 // PathPruningResults represents the number of files that were successfully
@@ -65,80 +211,438 @@ type ProcessingResults struct {
 type PathPruningResults struct {
 	SuccessfulRemovals matches.FileMatches
 	FailedRemovals     matches.FileMatches
+
+	// Files modified in place (e.g., compressed or truncated) instead of
+	// being removed.
+	Modified matches.FileMatches
+
+	// Files left as-is because they did not need the action (e.g., already
+	// compressed).
+	Skipped matches.FileMatches
+
+	// Outcome of the action applied to each file, in the order files were
+	// processed.
+	Outcomes []actions.Outcome
 }
 
-// CleanPath receives a slice of FileMatch objects and removes each file. Any
-// errors encountered while removing files may optionally be ignored via
+// record records the outcome of applying an action to a single file.
+func (ppr *PathPruningResults) record(outcome actions.Outcome) {
+
+	ppr.Outcomes = append(ppr.Outcomes, outcome)
+
+	switch {
+	case outcome.Status == actions.Failed:
+		ppr.FailedRemovals = append(ppr.FailedRemovals, outcome.File)
+	case outcome.Status == actions.Skipped:
+		ppr.Skipped = append(ppr.Skipped, outcome.File)
+	case outcome.Removed:
+		ppr.SuccessfulRemovals = append(ppr.SuccessfulRemovals, outcome.File)
+	default:
+		ppr.Modified = append(ppr.Modified, outcome.File)
+	}
+}
+
+// Add records the outcomes of another set of results, e.g., those of a
+// separate lifecycle stage applied to the same path.
+func (ppr *PathPruningResults) Add(results PathPruningResults) {
+	for _, outcome := range results.Outcomes {
+		ppr.record(outcome)
+	}
+}
+
+// SavedFileSize returns the number of bytes saved by modifying files in
+// place.
+func (ppr PathPruningResults) SavedFileSize() int64 {
+
+	var saved int64
+	for _, outcome := range ppr.Outcomes {
+		if outcome.Status == actions.Succeeded {
+			saved += outcome.Saved
+		}
+	}
+
+	return saved
+}
+
+// CleanPath receives a slice of FileMatch objects and applies the action
+// selected by the configuration to each file. Files are removed unless
+// another action (e.g., compressing or moving files) is configured. Any
+// errors encountered while applying the action may optionally be ignored via
 // command-line flag(default is to return immediately upon first error). The
-// total number of files successfully removed is returned along with an error
-// code (nil if no errors were encountered).
+// action is applied to up to the configured number of files at the same
+// time. The outcome for each file is returned in the order files were
+// provided, along with an error code (nil if no errors were encountered).
 func CleanPath(files matches.FileMatches, config *config.Config) (PathPruningResults, error) {
 
 	log := config.GetLogger()
 
 	for _, file := range files {
 		log.WithFields(logrus.Fields{
-			"fullpath":        strings.TrimSpace(file.Path),
-			"shortpath":       file.Name(),
+			"fullpath":        matches.DisplayName(strings.TrimSpace(file.Path)),
+			"shortpath":       matches.DisplayName(file.Name()),
 			"size":            file.Size(),
 			"modified":        file.ModTime().Format("2006-01-02 15:04:05"),
+			"content_type":    file.ContentType,
 			"removal_enabled": config.GetRemove(),
 		}).Debug("Matching file")
 	}
 
 	var removalResults PathPruningResults
 
+	action := actions.New(config)
+
 	if !config.GetRemove() {
 
 		log.Info("File removal not enabled, not removing files")
 
+		for _, file := range files {
+			log.WithFields(logrus.Fields{
+				"action": action.Name(),
+			}).Debugf("Dry run: would %s", action.Describe(file))
+		}
+
 		// Nothing to show for this yet, but since the initial state reflects
 		// that we can return it as-is
 		return removalResults, nil
 	}
 
-	for _, file := range files {
+	var prepareErr error
 
-		log.WithFields(logrus.Fields{
-			"removal_enabled": config.GetRemove(),
+	if preparer, ok := action.(actions.Preparer); ok {
 
-			// fully-qualified path to the file
-			"file": file.Path,
-		}).Debug("Removing file")
+		ready, failed, err := preparer.Prepare(files)
+
+		for _, outcome := range failed {
+			log.WithFields(logrus.Fields{
+				"action": outcome.Action,
+
+				// Include full details for troubleshooting purposes
+				"file": outcome.File,
+			}).Errorf("Error encountered while preparing file: %s", outcome.Err)
+
+			removalResults.record(outcome)
+			config.GetJournal().Done(journalPath(outcome.File.Path), outcome.Status.String())
+		}
+
+		if err == nil && len(failed) > 0 {
+			err = fmt.Errorf("%d files failed to %s", len(failed), action.Name())
+		}
+
+		if err != nil {
+			if !config.GetIgnoreErrors() {
+				return removalResults, err
+			}
+			prepareErr = err
+		}
+
+		// Only files that were prepared successfully may be processed.
+		files = ready
+	}
+
+	// Generate the run ID before applying the action concurrently.
+	config.GetRunID()
+
+	applied := applyAction(action, files, config.GetWorkers(), config.GetIgnoreErrors(), config.GetThrottle(), config.GetJournal())
+
+	// Results are recorded in the order files were provided, regardless of
+	// the order in which the action completed for each file.
+	var abandoned int
+	for i, file := range files {
+
+		if !applied[i].done {
+			abandoned++
+			continue
+		}
+
+		outcome, err := applied[i].outcome, applied[i].err
+
+		removalResults.record(outcome)
 
-		// We need to reference the full path here, not the short name since
-		// the current working directory may not be the same directory
-		// where the file is located
-		err := os.Remove(file.Path)
 		if err != nil {
 			log.WithFields(logrus.Fields{
+				"action": outcome.Action,
 
 				// Include full details for troubleshooting purposes
 				"file": file,
 			}).Errorf("Error encountered while removing file: %s", err)
 
-			// Record failed removal, proceed to the next file
-			removalResults.FailedRemovals = append(removalResults.FailedRemovals, file)
-
-			// Confirm that we should ignore errors (likely enabled)
-			if !config.GetIgnoreErrors() {
-				remainingFiles := len(files) - len(removalResults.FailedRemovals) - len(removalResults.SuccessfulRemovals)
-				log.Debugf("Abandoning removal of %d remaining files", remainingFiles)
-				break
+			if config.GetIgnoreErrors() {
+				log.Debug("Ignoring error as requested")
 			}
 
-			log.Debug("Ignoring error as requested")
 			continue
 		}
 
-		// Record successful removal
-		removalResults.SuccessfulRemovals = append(removalResults.SuccessfulRemovals, file)
+		if outcome.RollbackHint != "" {
+			log.WithFields(logrus.Fields{
+				"action":        outcome.Action,
+				"file":          matches.DisplayName(file.Path),
+				"rollback_hint": outcome.RollbackHint,
+			}).Debug("Action applied")
+		}
+	}
+
+	if abandoned > 0 {
+		log.Debugf("Abandoned removal of %d remaining files", abandoned)
 	}
 
-	return removalResults, nil
+	return removalResults, prepareErr
 
 }
 
+// appliedAction is the result of applying an action to a single file.
+type appliedAction struct {
+	done    bool
+	outcome actions.Outcome
+	err     error
+}
+
+// applyAction applies the action to the files using up to the specified
+// number of workers, waiting on the rate limits of the throttle (if any)
+// before each file. The result for each file is returned at the index of
+// the file. Unless errors are ignored, the action is not applied to any
+// further files once it fails for a file; files already being processed at
+// that time are completed, while all others are not processed and are
+// returned as not done. The outcome for each file is recorded in the journal
+// (if any) as soon as it is known.
+func applyAction(action actions.Action, files matches.FileMatches, workers int, ignoreErrors bool, throttle *throttle.Throttle, journal *journal.Journal) []appliedAction {
+
+	results := make([]appliedAction, len(files))
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+
+				// Files received after a failure was recorded are left as
+				// not done.
+				if ctx.Err() != nil {
+					continue
+				}
+
+				throttle.Remove(files[i].Size())
+				if ctx.Err() != nil {
+					continue
+				}
+
+				outcome, err := action.Apply(files[i])
+				results[i] = appliedAction{done: true, outcome: outcome, err: err}
+
+				journal.Done(journalPath(files[i].Path), outcome.Status.String())
+
+				if err != nil && !ignoreErrors {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for i := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+
+	return results
+}
+
+// StagedFiles represents the files handled by a single lifecycle stage along
+// with the configuration used to apply the stage.
+type StagedFiles struct {
+	Stage  config.Stage
+	Config *config.Config
+	Files  matches.FileMatches
+}
+
+// AssignStages assigns each file to the lifecycle stage with the greatest
+// age the file meets. Each file is evaluated independently, so a file which
+// meets the age of the delete stage is removed even if it was never
+// compressed or moved by an earlier stage. Stages are returned in the order
+// they were configured along with the files not yet due for any stage.
+func AssignStages(root string, files matches.FileMatches, config *config.Config) ([]StagedFiles, matches.FileMatches) {
+
+	staged := make([]StagedFiles, len(config.Stages))
+	for i, stage := range config.Stages {
+		staged[i] = StagedFiles{
+			Stage:  stage,
+			Config: config.ForStage(stage, root),
+		}
+	}
+
+	// Evaluate the stage with the greatest age first. Stages with equal age
+	// are evaluated in the order of compress, move and delete, with the
+	// later action taking precedence.
+	order := make([]int, len(staged))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := staged[order[i]].Stage, staged[order[j]].Stage
+		if a.Age != b.Age {
+			return a.Age > b.Age
+		}
+		return stageRank(a.Action) > stageRank(b.Action)
+	})
+
+	var unstaged matches.FileMatches
+
+	for _, file := range files {
+		assigned := false
+		for _, i := range order {
+			if matches.HasMatchingAge(file, staged[i].Config) {
+				staged[i].Files = append(staged[i].Files, file)
+				assigned = true
+				break
+			}
+		}
+		if !assigned {
+			unstaged = append(unstaged, file)
+		}
+	}
+
+	return staged, unstaged
+}
+
+// stageRank returns the position of a lifecycle stage action within the
+// compress, move and delete progression.
+func stageRank(action string) int {
+	switch action {
+	case config.StageActionCompress:
+		return 1
+	case config.StageActionMove:
+		return 2
+	case config.StageActionDelete:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// maxSymlinkHops is the maximum number of links followed when resolving a
+// chain of symlinks. This mirrors the limit commonly used by operating
+// systems to detect symlink loops.
+const maxSymlinkHops = 255
+
+// SymlinkTargets searches the provided paths for symlinks and returns the
+// canonical path of every file or directory along each symlink chain.
+// Subdirectories are searched only if recursive search is enabled. Broken
+// symlinks are skipped.
+func SymlinkTargets(config *config.Config, searchPaths []string) (map[string]struct{}, error) {
+
+	log := config.GetLogger()
+
+	targets := make(map[string]struct{})
+
+	for _, searchPath := range searchPaths {
+
+		err := filepath.WalkDir(searchPath, func(path string, d os.DirEntry, err error) error {
+
+			// Limit the rate at which entries are examined by the walk.
+			config.GetThrottle().Stat()
+
+			if err != nil {
+				if !config.GetIgnoreErrors() {
+					return err
+				}
+
+				log.WithFields(logrus.Fields{
+					"ignore_errors": config.GetIgnoreErrors(),
+				}).Warn("Error encountered while searching for symlinks:", err)
+
+				return nil
+			}
+
+			if d.IsDir() {
+				if path != searchPath && !config.GetRecursiveSearch() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if d.Type()&os.ModeSymlink == 0 {
+				return nil
+			}
+
+			link := path
+			for hop := 0; hop < maxSymlinkHops; hop++ {
+				target, err := os.Readlink(link)
+				if err != nil {
+					log.Debugf("Unable to read symlink %s: %s", link, err)
+					break
+				}
+
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(filepath.Dir(link), target)
+				}
+
+				canonical, err := matches.CanonicalPath(target)
+				if err != nil {
+					log.Debugf("Skipping broken symlink %s: %s", path, err)
+					break
+				}
+
+				log.WithFields(logrus.Fields{
+					"symlink": path,
+					"target":  canonical,
+				}).Debug("Found symlink target")
+
+				targets[canonical] = struct{}{}
+
+				info, err := os.Lstat(canonical)
+				if err != nil || info.Mode()&os.ModeSymlink == 0 {
+					break
+				}
+
+				link = canonical
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf(
+				"error searching %s for symlinks: %w",
+				searchPath,
+				err,
+			)
+		}
+	}
+
+	return targets, nil
+}
+
+// ProtectSymlinkTargets marks each file in the provided matches that is the
+// target of a symlink found in the specified path (or any additional
+// configured symlink location), or is located below a directory that is the
+// target of such a symlink, as protected. The number of newly protected files
+// is returned.
+func ProtectSymlinkTargets(config *config.Config, path string, fileMatches matches.FileMatches) (int, error) {
+
+	searchPaths := append([]string{path}, config.GetSymlinkLocations()...)
+
+	targets, err := SymlinkTargets(config, searchPaths)
+	if err != nil {
+		return 0, err
+	}
+
+	return fileMatches.Protect(targets, matches.ProtectedReasonSymlinkTarget), nil
+}
+
 // PathExists confirms that the specified path exists
 func PathExists(path string) (bool, error) {
 
@@ -166,21 +670,63 @@ func PathExists(path string) (bool, error) {
 
 }
 
+// sameDir indicates whether the two specified paths refer to the same
+// directory once symlinks are resolved.
+func sameDir(path1 string, path2 string) bool {
+
+	info1, err := os.Stat(path1)
+	if err != nil {
+		return false
+	}
+
+	info2, err := os.Stat(path2)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(info1, info2)
+}
+
+// ErrStalePath indicates that the newest matching file for a path is older
+// than the configured staleness limit. This usually means that whatever
+// produces the files has stopped, so pruning is skipped to avoid removing
+// every remaining copy.
+var ErrStalePath = errors.New("newest matching file exceeds staleness limit")
+
 // ProcessPath accepts a configuration object and a path to process and
-// returns a slice of FileMatch objects
-func ProcessPath(config *config.Config, path string) (matches.FileMatches, error) {
+// returns a slice of FileMatch objects along with the total number of files
+// (matching or not) found below the path.
+func ProcessPath(config *config.Config, path string) (matches.FileMatches, int, error) {
 
 	log := config.GetLogger()
 
-	var fileMatches matches.FileMatches
+	// Files matching the (cheap) filename based criteria. The remaining
+	// criteria are applied once the search is complete so that age can be
+	// evaluated relative to the newest candidate if requested.
+	var candidates matches.FileMatches
 	var err error
 
+	// Number of files found below the path, used to evaluate the share of
+	// files planned for removal.
+	var total int
+
 	log.WithFields(logrus.Fields{
 		"recursive_search": config.GetRecursiveSearch(),
 	}).Debugf("Recursive search: %t", config.GetRecursiveSearch())
 
 	if config.GetRecursiveSearch() {
 
+		// Directories holding files moved or copied out of the search path
+		// are skipped so that those files are not matched again, as is the
+		// journal directory.
+		var excludedDirs []string
+		for _, dir := range []string{config.GetQuarantineDir(), config.GetArchiveDir(), config.GetJournalDir()} {
+			if dir != "" {
+				excludedDirs = append(excludedDirs, dir)
+			}
+		}
+		excludedDirs = append(excludedDirs, config.GetStageMoveDirs()...)
+
 		// Walk walks the file tree rooted at root, calling the anonymous function
 		// for each file or directory in the tree, including root. All errors that
 		// arise visiting files and directories are filtered by the anonymous
@@ -189,6 +735,10 @@ func ProcessPath(config *config.Config, path string) (matches.FileMatches, error
 		// inefficient. Walk does not follow symbolic links.
 		err = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
 
+			// Limit the rate at which entries are examined (via lstat) by
+			// the walk.
+			config.GetThrottle().Stat()
+
 			// If an error is received, check to see whether we should ignore
 			// it or return it. If we return a non-nil error, this will stop
 			// the filepath.Walk() function from continuing to walk the path,
@@ -213,11 +763,21 @@ func ProcessPath(config *config.Config, path string) (matches.FileMatches, error
 			// make sure we're not working with the root directory itself
 			if path != "." {
 
-				// ignore directories
+				// ignore directories, skipping excluded directories entirely
 				if info.IsDir() {
+					for _, dir := range excludedDirs {
+						if sameDir(path, dir) {
+							log.WithFields(logrus.Fields{
+								"excluded_dir": dir,
+							}).Debug("Skipping excluded directory")
+							return filepath.SkipDir
+						}
+					}
 					return nil
 				}
 
+				total++
+
 				// ignore non-matching extension (only applies if user chose
 				// one or more extensions to match against)
 				if !matches.HasMatchingExtension(path, config) {
@@ -230,15 +790,11 @@ func ProcessPath(config *config.Config, path string) (matches.FileMatches, error
 					return nil
 				}
 
-				// ignore non-matching modification age
-				if !matches.HasMatchingAge(info, config) {
-					return nil
-				}
-
-				// If we made it to this point, then we must assume that the file
-				// has met all criteria to be removed by this application.
-				fileMatch := matches.FileMatch{FileInfo: info, Path: path}
-				fileMatches = append(fileMatches, fileMatch)
+				candidates = append(candidates, matches.FileMatch{
+					FileInfo: info,
+					Path:     path,
+					Identity: matches.NewIdentity(info),
+				})
 
 			}
 
@@ -273,9 +829,13 @@ func ProcessPath(config *config.Config, path string) (matches.FileMatches, error
 				continue
 			}
 
+			total++
+
+			config.GetThrottle().Stat()
+
 			fileInfo, err := file.Info()
 			if err != nil {
-				return nil, fmt.Errorf(
+				return nil, total, fmt.Errorf(
 					"file %s renamed or removed since directory read: %w",
 					fileInfo.Name(),
 					err,
@@ -297,21 +857,136 @@ func ProcessPath(config *config.Config, path string) (matches.FileMatches, error
 				continue
 			}
 
-			// ignore non-matching modification age
-			if !matches.HasMatchingAge(fileInfo, config) {
+			candidates = append(candidates, matches.FileMatch{
+				FileInfo: fileInfo,
+				Path:     filepath.Join(path, file.Name()),
+				Identity: matches.NewIdentity(fileInfo),
+			})
+		}
+	}
+
+	if err != nil || len(candidates) == 0 {
+		return nil, total, err
+	}
+
+	// matchContent applies the content type and file content criteria (only
+	// applies if user specified content criteria); these require reading the
+	// file and so are applied last where possible.
+	matchContent := func(candidate matches.FileMatch) (matches.FileMatch, bool) {
+		contentType, ok := matches.HasMatchingContentType(candidate.Path, config)
+		if !ok {
+			return candidate, false
+		}
+
+		if !matches.HasMatchingContent(candidate.Path, config) {
+			return candidate, false
+		}
+
+		candidate.ContentType = contentType
+		return candidate, true
+	}
+
+	// The newest fully matching file (regardless of age) serves as the
+	// reference point for relative age checks and for detecting a producer
+	// that has stopped, so content criteria are applied to all candidates
+	// first if a reference point is needed.
+	var newest matches.FileMatch
+	contentMatched := config.GetRelativeAge() || config.GetMaxStaleness() > 0
+	if contentMatched {
+		matched := make(matches.FileMatches, 0, len(candidates))
+		for _, candidate := range candidates {
+			if fileMatch, ok := matchContent(candidate); ok {
+				matched = append(matched, fileMatch)
+			}
+		}
+
+		if len(matched) == 0 {
+			return nil, total, nil
+		}
+
+		candidates = matched
+		newest = candidates.Newest()
+	}
+
+	if config.GetMaxStaleness() > 0 {
+		stalenessThreshold := matches.NewFileAgeThreshold(config.GetMaxStaleness(), config)
+		if newest.ModTime().Before(stalenessThreshold.Time()) {
+			return nil, total, fmt.Errorf(
+				"%w: %s last modified %s (limit %d days)",
+				ErrStalePath,
+				newest.Path,
+				newest.ModTime().Format(time.RFC3339),
+				config.GetMaxStaleness(),
+			)
+		}
+	}
+
+	fileMatches := make(matches.FileMatches, 0, len(candidates))
+
+	for _, candidate := range candidates {
+
+		// ignore non-matching modification age
+		if config.GetRelativeAge() {
+			if !matches.HasMatchingRelativeAge(candidate, newest.ModTime(), config) {
 				continue
 			}
+		} else if !matches.HasMatchingAge(candidate, config) {
+			continue
+		}
 
-			// If we made it to this point, then we must assume that the file
-			// has met all criteria to be removed by this application.
-			fileMatch := matches.FileMatch{
-				FileInfo: fileInfo,
-				Path:     filepath.Join(path, file.Name()),
+		// If we made it to this point, then we must assume that the file
+		// has met all criteria to be removed by this application.
+		fileMatch := candidate
+		if !contentMatched {
+			var ok bool
+			if fileMatch, ok = matchContent(candidate); !ok {
+				continue
 			}
+		}
+
+		// pinned files are retained as matches so that they count toward the
+		// number of files to keep
+		if matches.IsPinned(fileMatch.Path, config) {
+			fileMatch.ProtectedReason = matches.ProtectedReasonPinned
+		}
+
+		fileMatches = append(fileMatches, fileMatch)
+	}
+
+	// files used by this application are never removed, even if they are
+	// located below the path and meet all criteria
+	if appFiles := ApplicationFiles(config); len(appFiles) > 0 {
+		fileMatches.Protect(appFiles, matches.ProtectedReasonApplicationFile)
+	}
 
-			fileMatches = append(fileMatches, fileMatch)
+	return fileMatches, total, nil
+}
+
+// ApplicationFiles returns the canonical paths of files used by this
+// application: the configuration file, the log file, the stability state
+// file and the journal directory (if set). Files below the journal directory
+// are protected along with it.
+func ApplicationFiles(config *config.Config) map[string]struct{} {
+
+	files := make(map[string]struct{})
+
+	for _, file := range []string{
+		config.GetConfigFile(),
+		config.GetLogFilePath(),
+		config.GetStabilityStateFile(),
+		config.GetJournalDir(),
+	} {
+		if file == "" {
+			continue
+		}
+
+		canonical, err := matches.CanonicalPath(file)
+		if err != nil {
+			continue
 		}
+
+		files[canonical] = struct{}{}
 	}
 
-	return fileMatches, err
+	return files
 }
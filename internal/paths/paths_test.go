@@ -0,0 +1,345 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/atc0005/elbow/internal/actions"
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+)
+
+// failingAction fails for a single file and counts the files it was applied
+// to.
+type failingAction struct {
+	failPath string
+	applied  int32
+}
+
+func (a *failingAction) Name() string                               { return "failing" }
+func (a *failingAction) Describe(file matches.FileMatch) string     { return file.Path }
+func (a *failingAction) RollbackHint(file matches.FileMatch) string { return "" }
+
+func (a *failingAction) Apply(file matches.FileMatch) (actions.Outcome, error) {
+	atomic.AddInt32(&a.applied, 1)
+
+	var err error
+	if file.Path == a.failPath {
+		err = errors.New("simulated failure")
+	}
+
+	status := actions.Succeeded
+	if err != nil {
+		status = actions.Failed
+	}
+
+	return actions.Outcome{Action: a.Name(), File: file, Status: status, Err: err, Removed: err == nil}, err
+}
+
+func newTestFiles(t *testing.T, count int) matches.FileMatches {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	var files matches.FileMatches
+	for i := 0; i < count; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("file-%03d.log", i))
+		if err := os.WriteFile(path, []byte("worker test\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		files = append(files, matches.FileMatch{FileInfo: info, Path: path})
+	}
+
+	return files
+}
+
+func TestCleanPathWorkers(t *testing.T) {
+
+	files := newTestFiles(t, 50)
+
+	c := config.NewDefaultConfig()
+	*c.Remove = true
+	*c.Workers = 8
+	c.GetLogger().SetOutput(io.Discard)
+
+	results, err := CleanPath(files, &c)
+	if err != nil {
+		t.Fatalf("CleanPath() failed: %s", err)
+	}
+
+	if len(results.SuccessfulRemovals) != len(files) || len(results.FailedRemovals) != 0 {
+		t.Fatalf("got %d removed and %d failed files, want %d and 0",
+			len(results.SuccessfulRemovals), len(results.FailedRemovals), len(files))
+	}
+
+	for i, outcome := range results.Outcomes {
+		if outcome.File.Path != files[i].Path {
+			t.Errorf("outcome %d recorded for %s, want %s", i, outcome.File.Path, files[i].Path)
+		}
+
+		if _, err := os.Stat(files[i].Path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("file %s still present after CleanPath()", files[i].Path)
+		}
+	}
+}
+
+func TestApplyAction(t *testing.T) {
+
+	files := newTestFiles(t, 50)
+	failIndex := 10
+
+	tests := []struct {
+		name         string
+		workers      int
+		ignoreErrors bool
+	}{
+		{name: "sequential, stop on failure", workers: 1},
+		{name: "concurrent, stop on failure", workers: 4},
+		{name: "concurrent, ignore errors", workers: 4, ignoreErrors: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := &failingAction{failPath: files[failIndex].Path}
+
+			results := applyAction(action, files, tt.workers, tt.ignoreErrors, nil, nil)
+
+			if !results[failIndex].done || results[failIndex].err == nil {
+				t.Fatalf("failure for file %d not recorded: %+v", failIndex, results[failIndex])
+			}
+
+			var done int
+			for _, result := range results {
+				if result.done {
+					done++
+				}
+			}
+
+			if int(atomic.LoadInt32(&action.applied)) != done {
+				t.Errorf("action applied to %d files, but %d results recorded", action.applied, done)
+			}
+
+			switch {
+			case tt.ignoreErrors && done != len(files):
+				t.Errorf("got %d files processed, want all %d", done, len(files))
+			case !tt.ignoreErrors && tt.workers == 1 && done != failIndex+1:
+				t.Errorf("got %d files processed, want %d", done, failIndex+1)
+			case !tt.ignoreErrors && done > failIndex+tt.workers:
+				// At most the files already being processed by the other
+				// workers may complete after the failure.
+				t.Errorf("got %d files processed, want at most %d", done, failIndex+tt.workers)
+			}
+		})
+	}
+}
+
+func TestProcessPathProtectsApplicationFiles(t *testing.T) {
+
+	dir := t.TempDir()
+
+	logFile := filepath.Join(dir, "elbow.log")
+	configFile := filepath.Join(dir, "elbow.toml")
+	otherFile := filepath.Join(dir, "app.log")
+
+	journalDir := filepath.Join(dir, "journal")
+	journalFile := filepath.Join(journalDir, "run.jsonl")
+	if err := os.Mkdir(journalDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{logFile, configFile, otherFile, journalFile} {
+		if err := os.WriteFile(path, []byte("application file test\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := config.NewDefaultConfig()
+	c.GetLogger().SetOutput(io.Discard)
+	*c.LogFilePath = logFile
+	*c.ConfigFile = configFile
+	*c.JournalDir = journalDir
+	*c.RecursiveSearch = true
+
+	// The journal directory is skipped when searching recursively.
+	fileMatches, _, err := ProcessPath(&c, dir)
+	if err != nil {
+		t.Fatalf("ProcessPath() failed: %s", err)
+	}
+
+	if len(fileMatches) != 3 {
+		t.Fatalf("got %d matches, want 3", len(fileMatches))
+	}
+
+	for _, file := range fileMatches {
+		want := ""
+		if file.Path != otherFile {
+			want = matches.ProtectedReasonApplicationFile
+		}
+
+		if file.ProtectedReason != want {
+			t.Errorf("%s protected for reason %q, want %q", file.Path, file.ProtectedReason, want)
+		}
+	}
+
+	// Files in the journal directory are protected when searching it.
+	*c.RecursiveSearch = false
+	journalMatches, _, err := ProcessPath(&c, journalDir)
+	if err != nil {
+		t.Fatalf("ProcessPath() failed: %s", err)
+	}
+
+	if len(journalMatches) != 1 || journalMatches[0].ProtectedReason != matches.ProtectedReasonApplicationFile {
+		t.Errorf("got journal matches %v, want %s protected", journalMatches, journalFile)
+	}
+}
+
+func TestProtectSymlinkTargets(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires elevated privileges on Windows")
+	}
+
+	dir := t.TempDir()
+	releases := filepath.Join(dir, "releases")
+	linkedRelease := filepath.Join(releases, "1.4.2")
+
+	if err := os.MkdirAll(linkedRelease, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	linkedFile := filepath.Join(releases, "app-1.4.1.war")
+	fileInLinkedDir := filepath.Join(linkedRelease, "app.war")
+	otherFile := filepath.Join(releases, "app-1.4.0.war")
+
+	for _, path := range []string{linkedFile, fileInLinkedDir, otherFile} {
+		if err := os.WriteFile(path, []byte("symlink test\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := map[string]string{
+		filepath.Join(dir, "previous"): linkedFile,
+		filepath.Join(dir, "current"):  linkedRelease,
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := config.NewDefaultConfig()
+	c.GetLogger().SetOutput(io.Discard)
+
+	var fileMatches matches.FileMatches
+	for _, path := range []string{linkedFile, fileInLinkedDir, otherFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileMatches = append(fileMatches, matches.FileMatch{FileInfo: info, Path: path})
+	}
+
+	protected, err := ProtectSymlinkTargets(&c, dir, fileMatches)
+	if err != nil {
+		t.Fatalf("ProtectSymlinkTargets() failed: %s", err)
+	}
+
+	if protected != 2 {
+		t.Errorf("got %d protected files, want 2", protected)
+	}
+
+	for _, file := range fileMatches {
+		want := matches.ProtectedReasonSymlinkTarget
+		if file.Path == otherFile {
+			want = ""
+		}
+
+		if file.ProtectedReason != want {
+			t.Errorf("%s protected for reason %q, want %q", file.Path, file.ProtectedReason, want)
+		}
+	}
+}
+
+func TestProcessPathNewestMatch(t *testing.T) {
+
+	dir := t.TempDir()
+	now := time.Now()
+
+	// The newest file does not match the content criteria, so the newest
+	// match is "recent.log".
+	files := map[string]struct {
+		content string
+		age     time.Duration
+	}{
+		"oldest.log": {content: "build complete\n", age: 10 * 24 * time.Hour},
+		"older.log":  {content: "build complete\n", age: 7 * 24 * time.Hour},
+		"recent.log": {content: "build complete\n", age: 5 * 24 * time.Hour},
+		"newest.log": {content: "build running\n", age: time.Hour},
+	}
+
+	for name, file := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(file.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(-file.age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := config.NewDefaultConfig()
+	c.GetLogger().SetOutput(io.Discard)
+	*c.ContentPattern = "complete"
+	*c.RelativeAge = true
+	*c.FileAge = 3
+
+	fileMatches, _, err := ProcessPath(&c, dir)
+	if err != nil {
+		t.Fatalf("ProcessPath() failed: %s", err)
+	}
+
+	if len(fileMatches) != 1 || filepath.Base(fileMatches[0].Path) != "oldest.log" {
+		var names []string
+		for _, file := range fileMatches {
+			names = append(names, filepath.Base(file.Path))
+		}
+		t.Errorf("got matches %v, want [oldest.log]", names)
+	}
+
+	*c.RelativeAge = false
+	*c.MaxStaleness = 4
+
+	if _, _, err := ProcessPath(&c, dir); !errors.Is(err, ErrStalePath) {
+		t.Errorf("got error %v, want %v", err, ErrStalePath)
+	}
+}
@@ -0,0 +1,108 @@
+//go:build linux
+// +build linux
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"golang.org/x/sys/unix"
+)
+
+func TestPinnedFilesCountTowardKeep(t *testing.T) {
+
+	dir := t.TempDir()
+	modTime := time.Now().Add(-time.Hour)
+
+	var files []string
+	for i := 0; i < 3; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("app-%d.log", i))
+		if err := os.WriteFile(path, []byte("pin test\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		fileTime := modTime.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, fileTime, fileTime); err != nil {
+			t.Fatal(err)
+		}
+
+		files = append(files, path)
+	}
+
+	c := config.NewDefaultConfig()
+	*c.NumFilesToKeep = 1
+	c.GetLogger().SetOutput(io.Discard)
+
+	pruned := func() map[string]bool {
+		t.Helper()
+
+		fileMatches, _, err := ProcessPath(&c, dir)
+		if err != nil {
+			t.Fatalf("ProcessPath() failed: %s", err)
+		}
+
+		paths := make(map[string]bool)
+		for _, file := range fileMatches.FilesToPrune(&c) {
+			paths[file.Path] = true
+		}
+		return paths
+	}
+
+	// Find the file kept without any pins.
+	before := pruned()
+	if len(before) != 2 {
+		t.Fatalf("got %d files to prune, want 2", len(before))
+	}
+
+	var kept string
+	for _, path := range files {
+		if !before[path] {
+			kept = path
+		}
+	}
+
+	// Pin the kept file. It still counts toward the number of files to
+	// keep, so the other files are pruned as before instead of one of them
+	// being kept in its place.
+	err := matches.Pin(kept, c.GetPinAttribute(), time.Time{})
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		t.Skipf("extended attributes not supported: %s", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := pruned()
+	if len(after) != len(before) || after[kept] {
+		t.Errorf("got files to prune %v, want %v", after, before)
+	}
+	for path := range before {
+		if !after[path] {
+			t.Errorf("%s not pruned after pinning %s", path, kept)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paths
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+)
+
+func TestAssignStages(t *testing.T) {
+
+	root := t.TempDir()
+	archiveDir := filepath.Join(t.TempDir(), "archive")
+
+	c := config.NewDefaultConfig()
+	*c.Remove = true
+	*c.AsOf = "2020-12-31T00:00:00Z"
+	*c.Timezone = "UTC"
+	c.Stages = []config.Stage{
+		{Action: config.StageActionCompress, Age: 7},
+		{Action: config.StageActionMove, Age: 30, Destination: archiveDir},
+		{Action: config.StageActionDelete, Age: 365},
+	}
+	c.GetLogger().SetOutput(io.Discard)
+
+	reference := time.Date(2020, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	ages := map[string]int{
+		"new.log":       1,
+		"week.log":      10,
+		"sub/month.log": 45,
+		"year.log":      400,
+	}
+
+	var files matches.FileMatches
+	for name, age := range ages {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+		modTime := reference.AddDate(0, 0, -age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, matches.FileMatch{FileInfo: info, Path: path})
+	}
+
+	staged, unstaged := AssignStages(root, files, &c)
+
+	if len(unstaged) != 1 || unstaged[0].Name() != "new.log" {
+		t.Errorf("got unstaged files %v, want only new.log", unstaged)
+	}
+
+	want := map[string]string{
+		config.StageActionCompress: "week.log",
+		config.StageActionMove:     "month.log",
+		config.StageActionDelete:   "year.log",
+	}
+
+	for _, stage := range staged {
+		if len(stage.Files) != 1 || stage.Files[0].Name() != want[stage.Stage.Action] {
+			t.Errorf("got files %v for stage %q, want only %s", stage.Files, stage.Stage.Action, want[stage.Stage.Action])
+			continue
+		}
+
+		results, err := CleanPath(stage.Files, stage.Config)
+		if err != nil {
+			t.Fatalf("CleanPath() failed for stage %q: %s", stage.Stage.Action, err)
+		}
+
+		if len(results.FailedRemovals) != 0 {
+			t.Errorf("got %d failures for stage %q", len(results.FailedRemovals), stage.Stage.Action)
+		}
+	}
+
+	for _, path := range []string{
+		filepath.Join(root, "new.log"),
+		filepath.Join(root, "week.log.gz"),
+		filepath.Join(archiveDir, "sub", "month.log"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected file missing after applying stages: %s", err)
+		}
+	}
+
+	for _, path := range []string{
+		filepath.Join(root, "week.log"),
+		filepath.Join(root, "sub", "month.log"),
+		filepath.Join(root, "year.log"),
+	} {
+		if _, err := os.Stat(path); err == nil {
+			t.Errorf("file %s still present after applying stages", path)
+		}
+	}
+}
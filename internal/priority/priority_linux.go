@@ -0,0 +1,102 @@
+//go:build linux
+// +build linux
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priority
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// I/O priority values as defined by linux/ioprio.h.
+const (
+	ioprioWhoProcess = 1
+	ioprioClassIdle  = 3
+	ioprioClassShift = 13
+)
+
+// SetIdleIO places all threads of this process in the idle I/O scheduling
+// class, so that this process is only granted disk time when no other
+// process needs it.
+func SetIdleIO() error {
+	return forEachThread(func(tid int) error {
+		_, _, errno := unix.Syscall(
+			unix.SYS_IOPRIO_SET,
+			ioprioWhoProcess,
+			uintptr(tid),
+			ioprioClassIdle<<ioprioClassShift,
+		)
+		if errno != 0 {
+			return errno
+		}
+		return nil
+	})
+}
+
+// SetNice sets the nice value of all threads of this process.
+func SetNice(nice int) error {
+	return forEachThread(func(tid int) error {
+		return unix.Setpriority(unix.PRIO_PROCESS, tid, nice)
+	})
+}
+
+// forEachThread applies fn to every thread of this process. Linux applies
+// scheduling priorities per thread, while threads created later inherit the
+// priority of the thread creating them. Threads are listed repeatedly until
+// no new threads are found so that threads started by the Go runtime in the
+// meantime are not missed. Threads which exit while being updated are
+// ignored.
+func forEachThread(fn func(tid int) error) error {
+
+	done := make(map[int]struct{})
+
+	for {
+		entries, err := os.ReadDir("/proc/self/task")
+		if err != nil {
+			return fmt.Errorf("failed to list threads: %w", err)
+		}
+
+		var updated bool
+		for _, entry := range entries {
+			tid, err := strconv.Atoi(entry.Name())
+			if err != nil {
+				continue
+			}
+
+			if _, ok := done[tid]; ok {
+				continue
+			}
+
+			if err := fn(tid); err != nil && !errors.Is(err, unix.ESRCH) {
+				return fmt.Errorf("failed to update thread %d: %w", tid, err)
+			}
+
+			done[tid] = struct{}{}
+			updated = true
+		}
+
+		if !updated {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,60 @@
+//go:build linux
+// +build linux
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priority
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestSetIdleIO(t *testing.T) {
+
+	if err := SetIdleIO(); err != nil {
+		t.Fatalf("SetIdleIO() failed: %s", err)
+	}
+
+	ioprio, _, errno := unix.Syscall(unix.SYS_IOPRIO_GET, ioprioWhoProcess, 0, 0)
+	if errno != 0 {
+		t.Fatalf("failed to read I/O priority: %s", errno)
+	}
+
+	if class := ioprio >> ioprioClassShift; class != ioprioClassIdle {
+		t.Errorf("got I/O scheduling class %d, want %d", class, ioprioClassIdle)
+	}
+}
+
+func TestSetNice(t *testing.T) {
+
+	nice := 19
+	if err := SetNice(nice); err != nil {
+		t.Fatalf("SetNice() failed: %s", err)
+	}
+
+	// The kernel reports the priority as 20 - nice.
+	got, err := unix.Getpriority(unix.PRIO_PROCESS, 0)
+	if err != nil {
+		t.Fatalf("failed to read priority: %s", err)
+	}
+
+	if got != 20-nice {
+		t.Errorf("got nice value %d, want %d", 20-got, nice)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !linux
+// +build !linux
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priority
+
+// SetIdleIO is not supported on this platform.
+func SetIdleIO() error {
+	return ErrNotSupported
+}
+
+// SetNice is not supported on this platform.
+func SetNice(_ int) error {
+	return ErrNotSupported
+}
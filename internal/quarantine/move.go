@@ -0,0 +1,191 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quarantine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/unlink"
+)
+
+// ErrDestinationExists indicates that a file could not be moved because the
+// destination path is already in use.
+var ErrDestinationExists = errors.New("destination already exists")
+
+// Move moves the file at the specified source path to destination,
+// preserving its permissions and modification time. The file is moved
+// relative to its parent directory, opened below root in the same way as by
+// unlink.File, and only once confirmed to match the provided identity;
+// unlink.ErrChanged is returned (wrapped) otherwise. Files are renamed where
+// possible. If source and destination are on different filesystems the file
+// is copied, the copy is verified against the original and only then is the
+// original removed. An existing destination is never replaced.
+func Move(root string, source string, destination string, expected matches.Identity) error {
+
+	if _, err := os.Lstat(destination); err == nil {
+		return fmt.Errorf("%w: %s", ErrDestinationExists, destination)
+	}
+
+	parent, err := unlink.OpenParent(root, source)
+	if err != nil {
+		return err
+	}
+	defer parent.Close()
+
+	if err := parent.Check(expected); err != nil {
+		return err
+	}
+
+	err = parent.RenameTo(destination)
+	switch {
+	case err == nil:
+		return nil
+	case !isCrossDevice(err):
+		return err
+	}
+
+	return copyVerifyDelete(parent, destination, expected)
+}
+
+// copyVerifyDelete copies the file to destination, confirms that the content
+// of the copy matches the original and then removes the original. The copy
+// is removed if any step fails.
+func copyVerifyDelete(parent *unlink.Parent, destination string, expected matches.Identity) (err error) {
+
+	source := parent.Path()
+
+	in, err := parent.Open(os.O_RDONLY, expected)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	sourceInfo, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	sourceSum, err := copyFile(in, destination, sourceInfo)
+
+	// Don't leave a partial or unverified copy behind.
+	defer func() {
+		if err != nil {
+			_ = os.Remove(destination)
+		}
+	}()
+
+	if err != nil {
+		return fmt.Errorf("unable to copy %s: %w", source, err)
+	}
+
+	destinationSum, err := checksum(destination)
+	if err != nil {
+		return fmt.Errorf("unable to verify copy of %s: %w", source, err)
+	}
+
+	if !bytes.Equal(sourceSum, destinationSum) {
+		err = fmt.Errorf("verification of copy of %s failed: checksum mismatch", source)
+		return err
+	}
+
+	// Confirm that the original was not replaced or written to while it was
+	// copied. The original is closed first as open files cannot be removed
+	// on Windows.
+	_ = in.Close()
+	if err = parent.Check(matches.NewIdentity(sourceInfo)); err != nil {
+		return err
+	}
+
+	if err = parent.Remove(); err != nil {
+		return fmt.Errorf("unable to remove %s after copy: %w", source, err)
+	}
+
+	return nil
+}
+
+// copyFile copies the content read from in, along with the permissions,
+// modification time and (where supported) ownership of the original, to a
+// new destination file. The SHA-256 checksum of the content read is
+// returned.
+func copyFile(in *os.File, destination string, sourceInfo os.FileInfo) ([]byte, error) {
+
+	out, err := os.OpenFile(
+		filepath.Clean(destination),
+		os.O_WRONLY|os.O_CREATE|os.O_EXCL,
+		sourceInfo.Mode().Perm(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(io.MultiWriter(out, hash), in); err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+
+	// Apply the permissions explicitly as the mode used when creating the
+	// file is subject to the umask.
+	if err := os.Chmod(destination, sourceInfo.Mode().Perm()); err != nil {
+		return nil, err
+	}
+
+	if err := preserveOwner(destination, sourceInfo); err != nil {
+		return nil, err
+	}
+
+	if err := os.Chtimes(destination, sourceInfo.ModTime(), sourceInfo.ModTime()); err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
+}
+
+// checksum returns the SHA-256 checksum of the content of the specified
+// file.
+func checksum(path string) ([]byte, error) {
+
+	fh, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, fh); err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
+}
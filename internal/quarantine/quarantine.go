@@ -0,0 +1,504 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package quarantine provides types and functions used to move files into a
+// quarantine directory instead of removing them, to restore quarantined files
+// and to purge the quarantine directory once files have been held long
+// enough.
+//
+// Quarantined files are stored below a directory named after the run ID of
+// the application run that quarantined them, preserving the full original
+// path of each file. Each run directory holds a manifest recording the
+// original path, quarantine time and size of every file moved into it.
+package quarantine
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/sirupsen/logrus"
+)
+
+// ManifestFilename is the name of the manifest file stored in each run
+// directory.
+const ManifestFilename string = "manifest.jsonl"
+
+// ErrNotFound indicates that no quarantined files matched the restore
+// request.
+var ErrNotFound = errors.New("no matching quarantined files found")
+
+// Entry represents a manifest record for a single quarantined file.
+type Entry struct {
+	RunID          string      `json:"run_id"`
+	OriginalPath   string      `json:"original_path"`
+	QuarantinePath string      `json:"quarantine_path"`
+	QuarantinedAt  time.Time   `json:"quarantined_at"`
+	Size           int64       `json:"size"`
+	ModTime        time.Time   `json:"mod_time"`
+	Mode           os.FileMode `json:"mode"`
+}
+
+// RunDir returns the directory used to hold files quarantined by the
+// specified run.
+func RunDir(quarantineDir string, runID string) string {
+	return filepath.Join(quarantineDir, runID)
+}
+
+// Destination returns the path used to hold the specified file within the
+// run directory. The full original path is preserved below the run directory
+// so that files from different paths cannot collide.
+func Destination(quarantineDir string, runID string, originalPath string) (string, error) {
+
+	absPath, err := filepath.Abs(originalPath)
+	if err != nil {
+		return "", err
+	}
+
+	// Replace the volume name (e.g., "C:") with a plain directory name so
+	// that the result remains a valid relative path.
+	volume := filepath.VolumeName(absPath)
+	relPath := strings.TrimPrefix(absPath, volume)
+	volume = strings.TrimSuffix(volume, ":")
+
+	return filepath.Join(RunDir(quarantineDir, runID), volume, relPath), nil
+}
+
+// Quarantine moves the specified file into the run directory for the current
+// application run and records it in the manifest for that run. The manifest
+// entry is returned.
+func Quarantine(file matches.FileMatch, config *config.Config) (Entry, error) {
+
+	runDir := RunDir(config.GetQuarantineDir(), config.GetRunID())
+
+	destination, err := Destination(config.GetQuarantineDir(), config.GetRunID(), file.Path)
+	if err != nil {
+		return Entry{}, fmt.Errorf("unable to determine quarantine path for %s: %w", file.Path, err)
+	}
+
+	absPath, err := filepath.Abs(file.Path)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0700); err != nil {
+		return Entry{}, fmt.Errorf("unable to create quarantine directory: %w", err)
+	}
+
+	id := file.Identity
+	if id.IsZero() {
+		id = matches.NewIdentity(file.FileInfo)
+	}
+
+	if err := Move(config.GetSearchRoot(), file.Path, destination, id); err != nil {
+		return Entry{}, fmt.Errorf("unable to move %s to quarantine: %w", file.Path, err)
+	}
+
+	entry := Entry{
+		RunID:          config.GetRunID(),
+		OriginalPath:   absPath,
+		QuarantinePath: destination,
+		QuarantinedAt:  time.Now(),
+		Size:           file.Size(),
+		ModTime:        file.ModTime(),
+		Mode:           file.Mode(),
+	}
+
+	if err := appendManifest(runDir, entry); err != nil {
+
+		// A file without a manifest entry cannot be restored, so put it back
+		// where we found it.
+		if moveErr := release(config.GetQuarantineDir(), destination, file.Path); moveErr != nil {
+			return Entry{}, fmt.Errorf(
+				"unable to record %s in manifest (%v) or return it to its original location: %w",
+				file.Path, err, moveErr,
+			)
+		}
+
+		return Entry{}, fmt.Errorf("unable to record %s in manifest: %w", file.Path, err)
+	}
+
+	return entry, nil
+}
+
+// Runs returns the IDs of all runs held in the quarantine directory, oldest
+// first.
+func Runs(quarantineDir string) ([]string, error) {
+
+	dirEntries, err := os.ReadDir(quarantineDir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("unable to read quarantine directory: %w", err)
+	}
+
+	runs := make([]string, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			runs = append(runs, dirEntry.Name())
+		}
+	}
+
+	sort.Strings(runs)
+
+	return runs, nil
+}
+
+// LoadManifest reads all entries from the manifest in the specified run
+// directory.
+func LoadManifest(runDir string) ([]Entry, error) {
+
+	manifest := filepath.Join(runDir, ManifestFilename)
+
+	fh, err := os.Open(filepath.Clean(manifest))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open manifest: %w", err)
+	}
+	defer fh.Close()
+
+	var entries []Entry
+
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("unable to parse manifest %s: %w", manifest, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read manifest %s: %w", manifest, err)
+	}
+
+	return entries, nil
+}
+
+// manifestMu serializes manifest updates made while quarantining files
+// concurrently.
+var manifestMu sync.Mutex
+
+// appendManifest records the specified entry in the manifest held in the run
+// directory.
+func appendManifest(runDir string, entry Entry) error {
+
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	manifest := filepath.Join(runDir, ManifestFilename)
+
+	fh, err := os.OpenFile(filepath.Clean(manifest), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fh.Write(append(content, '\n')); err != nil {
+		_ = fh.Close()
+		return err
+	}
+
+	if err := fh.Sync(); err != nil {
+		_ = fh.Close()
+		return err
+	}
+
+	return fh.Close()
+}
+
+// writeManifest replaces the manifest held in the run directory with the
+// specified entries. The manifest is removed if no entries remain.
+func writeManifest(runDir string, entries []Entry) error {
+
+	manifest := filepath.Join(runDir, ManifestFilename)
+
+	if len(entries) == 0 {
+		return os.Remove(manifest)
+	}
+
+	var content []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		content = append(content, line...)
+		content = append(content, '\n')
+	}
+
+	tmpFile := manifest + ".tmp"
+	if err := os.WriteFile(tmpFile, content, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, manifest)
+}
+
+// RestoreResults represents the results of a restore request.
+type RestoreResults struct {
+	Restored []Entry
+	Failed   []Entry
+}
+
+// Restore moves quarantined files back to their original location. All files
+// quarantined by the specified runs are restored along with any files whose
+// original path is listed. If both runs and files are specified, only listed
+// files quarantined by the specified runs are restored. Files are never
+// restored over an existing file.
+func Restore(config *config.Config, runs []string, files []string) (RestoreResults, error) {
+
+	log := config.GetLogger()
+
+	var results RestoreResults
+
+	requestedRuns := make(map[string]struct{}, len(runs))
+	for _, run := range runs {
+		requestedRuns[run] = struct{}{}
+	}
+
+	requestedFiles := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		absPath, err := filepath.Abs(file)
+		if err != nil {
+			return results, err
+		}
+		requestedFiles[absPath] = struct{}{}
+	}
+
+	available, err := Runs(config.GetQuarantineDir())
+	if err != nil {
+		return results, err
+	}
+
+	for _, run := range available {
+
+		if _, ok := requestedRuns[run]; len(requestedRuns) > 0 && !ok {
+			continue
+		}
+
+		runDir := RunDir(config.GetQuarantineDir(), run)
+
+		entries, err := LoadManifest(runDir)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"run_id": run,
+			}).Warnf("Skipping run: %s", err)
+			continue
+		}
+
+		remaining := make([]Entry, 0, len(entries))
+
+		for _, entry := range entries {
+
+			if _, ok := requestedFiles[entry.OriginalPath]; len(requestedFiles) > 0 && !ok {
+				remaining = append(remaining, entry)
+				continue
+			}
+
+			contextLogger := log.WithFields(logrus.Fields{
+				"run_id":          entry.RunID,
+				"original_path":   matches.DisplayName(entry.OriginalPath),
+				"quarantine_path": matches.DisplayName(entry.QuarantinePath),
+			})
+
+			if err := restoreEntry(config.GetQuarantineDir(), entry); err != nil {
+				contextLogger.Errorf("Error encountered while restoring file: %s", err)
+				results.Failed = append(results.Failed, entry)
+				remaining = append(remaining, entry)
+				continue
+			}
+
+			contextLogger.Debug("Restored file")
+			results.Restored = append(results.Restored, entry)
+		}
+
+		if len(remaining) == len(entries) {
+			continue
+		}
+
+		if err := writeManifest(runDir, remaining); err != nil {
+			return results, fmt.Errorf("unable to update manifest for run %s: %w", run, err)
+		}
+
+		if len(remaining) == 0 {
+			if err := removeEmptyDirs(runDir); err != nil {
+				log.WithFields(logrus.Fields{
+					"run_id": run,
+				}).Warnf("Unable to remove empty run directory: %s", err)
+			}
+		}
+	}
+
+	if len(results.Restored) == 0 && len(results.Failed) == 0 {
+		return results, ErrNotFound
+	}
+
+	return results, nil
+}
+
+// restoreEntry moves a single quarantined file back to its original
+// location, recreating the original parent directory if needed.
+func restoreEntry(quarantineDir string, entry Entry) error {
+
+	if err := os.MkdirAll(filepath.Dir(entry.OriginalPath), 0750); err != nil {
+		return fmt.Errorf("unable to create original directory: %w", err)
+	}
+
+	return release(quarantineDir, entry.QuarantinePath, entry.OriginalPath)
+}
+
+// release moves a quarantined file from the quarantine directory to the
+// destination path.
+func release(quarantineDir string, path string, destination string) error {
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	return Move(quarantineDir, path, destination, matches.NewIdentity(info))
+}
+
+// Purge removes runs from the quarantine directory once all files held by
+// the run are older than the configured quarantine retention period,
+// measured from the current time. Runs are only removed if file removal is
+// enabled. The IDs of all runs eligible for
+// purging are returned.
+func Purge(config *config.Config) ([]string, error) {
+
+	log := config.GetLogger()
+
+	if config.GetQuarantineDir() == "" || config.GetQuarantineRetention() == 0 {
+		return nil, nil
+	}
+
+	// Files are stamped with the wall-clock time when quarantined, so the
+	// retention period is measured from the current time even if an "as of"
+	// time was specified.
+	threshold := matches.NewFileAgeThresholdFrom(config.GetQuarantineRetention(), time.Now(), config)
+
+	runs, err := Runs(config.GetQuarantineDir())
+	if err != nil {
+		return nil, err
+	}
+
+	var purged []string
+
+	for _, run := range runs {
+
+		// Leave files quarantined by the current run alone regardless of
+		// the reference time used.
+		if run == config.GetRunID() {
+			continue
+		}
+
+		runDir := RunDir(config.GetQuarantineDir(), run)
+
+		contextLogger := log.WithFields(logrus.Fields{
+			"run_id":               run,
+			"quarantine_threshold": threshold.FormatLog(),
+		})
+
+		entries, err := LoadManifest(runDir)
+		if err != nil {
+			contextLogger.Warnf("Skipping purge of run: %s", err)
+			continue
+		}
+
+		var newest time.Time
+		for _, entry := range entries {
+			if entry.QuarantinedAt.After(newest) {
+				newest = entry.QuarantinedAt
+			}
+		}
+
+		if newest.After(threshold.Time()) {
+			contextLogger.Debug("Run has not exceeded quarantine retention")
+			continue
+		}
+
+		if config.GetRemove() {
+			if err := os.RemoveAll(runDir); err != nil {
+				return purged, fmt.Errorf("unable to purge run %s: %w", run, err)
+			}
+		}
+
+		contextLogger.WithFields(logrus.Fields{
+			"removal_enabled": config.GetRemove(),
+			"files":           len(entries),
+		}).Debug("Run eligible for purging")
+
+		purged = append(purged, run)
+	}
+
+	return purged, nil
+}
+
+// removeEmptyDirs removes the specified directory and all directories below
+// it that do not contain any files. Directories that still contain files are
+// left in place.
+func removeEmptyDirs(root string) error {
+
+	var dirs []string
+
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Remove the deepest directories first.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dirEntries, err := os.ReadDir(dirs[i])
+		if err != nil {
+			return err
+		}
+		if len(dirEntries) > 0 {
+			continue
+		}
+		if err := os.Remove(dirs[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
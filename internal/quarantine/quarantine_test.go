@@ -0,0 +1,259 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quarantine
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/atc0005/elbow/internal/config"
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/unlink"
+)
+
+func newTestFileMatch(t *testing.T, path string, modTime time.Time) matches.FileMatch {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("quarantine test"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return matches.FileMatch{FileInfo: info, Path: path}
+}
+
+func TestQuarantineAndRestore(t *testing.T) {
+
+	baseDir := t.TempDir()
+	searchDir := filepath.Join(baseDir, "search")
+	quarantineDir := filepath.Join(baseDir, "quarantine")
+
+	c := config.NewDefaultConfig()
+	*c.QuarantineDir = quarantineDir
+	c.GetLogger().SetOutput(io.Discard)
+
+	modTime := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	files := []matches.FileMatch{
+		newTestFileMatch(t, filepath.Join(searchDir, "a.tmp"), modTime),
+		newTestFileMatch(t, filepath.Join(searchDir, "sub", "b.tmp"), modTime),
+	}
+
+	for _, file := range files {
+		entry, err := Quarantine(file, &c)
+		if err != nil {
+			t.Fatalf("Quarantine() failed: %s", err)
+		}
+
+		if _, err := os.Stat(file.Path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("original file %s still present after quarantine", file.Path)
+		}
+
+		info, err := os.Stat(entry.QuarantinePath)
+		if err != nil {
+			t.Fatalf("quarantined file missing: %s", err)
+		}
+
+		if !info.ModTime().Equal(modTime) {
+			t.Errorf("got modification time %s, want %s", info.ModTime(), modTime)
+		}
+
+		if info.Mode().Perm() != 0640 {
+			t.Errorf("got permissions %o, want %o", info.Mode().Perm(), 0640)
+		}
+	}
+
+	runDir := RunDir(quarantineDir, c.GetRunID())
+	entries, err := LoadManifest(runDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != len(files) {
+		t.Fatalf("got %d manifest entries, want %d", len(entries), len(files))
+	}
+
+	// Restore a single file first, followed by the rest of the run.
+	results, err := Restore(&c, nil, []string{files[1].Path})
+	if err != nil {
+		t.Fatalf("Restore() of single file failed: %s", err)
+	}
+
+	if len(results.Restored) != 1 || results.Restored[0].OriginalPath != files[1].Path {
+		t.Fatalf("unexpected restore results: %+v", results)
+	}
+
+	results, err = Restore(&c, []string{c.GetRunID()}, nil)
+	if err != nil {
+		t.Fatalf("Restore() of run failed: %s", err)
+	}
+
+	if len(results.Restored) != 1 || results.Restored[0].OriginalPath != files[0].Path {
+		t.Fatalf("unexpected restore results: %+v", results)
+	}
+
+	for _, file := range files {
+		if _, err := os.Stat(file.Path); err != nil {
+			t.Errorf("file %s not restored: %s", file.Path, err)
+		}
+	}
+
+	if _, err := os.Stat(runDir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("run directory %s still present after restoring all files", runDir)
+	}
+
+	if _, err := Restore(&c, []string{c.GetRunID()}, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestMoveRefusesExistingDestination(t *testing.T) {
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source.tmp")
+	destination := filepath.Join(dir, "destination.tmp")
+
+	for _, path := range []string{source, destination} {
+		if err := os.WriteFile(path, []byte(path), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := os.Lstat(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Move(dir, source, destination, matches.NewIdentity(info)); !errors.Is(err, ErrDestinationExists) {
+		t.Errorf("got error %v, want %v", err, ErrDestinationExists)
+	}
+
+	if _, err := os.Stat(source); err != nil {
+		t.Errorf("source removed after failed move: %s", err)
+	}
+}
+
+func TestMoveRefusesChangedFile(t *testing.T) {
+
+	dir := t.TempDir()
+	file := newTestFileMatch(t, filepath.Join(dir, "source.tmp"), time.Now())
+	destination := filepath.Join(dir, "destination.tmp")
+
+	if err := os.WriteFile(file.Path, []byte("rewritten since it was found"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Move(dir, file.Path, destination, matches.NewIdentity(file.FileInfo)); !errors.Is(err, unlink.ErrChanged) {
+		t.Errorf("got error %v, want %v", err, unlink.ErrChanged)
+	}
+
+	if _, err := os.Stat(file.Path); err != nil {
+		t.Errorf("source moved after failed move: %s", err)
+	}
+
+	if _, err := os.Lstat(destination); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("destination %s present after failed move", destination)
+	}
+}
+
+func TestCopyVerifyDelete(t *testing.T) {
+
+	dir := t.TempDir()
+	modTime := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	file := newTestFileMatch(t, filepath.Join(dir, "source.tmp"), modTime)
+	destination := filepath.Join(dir, "destination.tmp")
+
+	parent, err := unlink.OpenParent(dir, file.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parent.Close()
+
+	if err := copyVerifyDelete(parent, destination, matches.NewIdentity(file.FileInfo)); err != nil {
+		t.Fatalf("copyVerifyDelete() failed: %s", err)
+	}
+
+	if _, err := os.Stat(file.Path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("source still present after copy")
+	}
+
+	info, err := os.Stat(destination)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("got modification time %s, want %s", info.ModTime(), modTime)
+	}
+
+	if info.Size() != file.Size() {
+		t.Errorf("got size %d, want %d", info.Size(), file.Size())
+	}
+}
+
+func TestPurgeIgnoresAsOf(t *testing.T) {
+
+	baseDir := t.TempDir()
+	quarantineDir := filepath.Join(baseDir, "quarantine")
+
+	c := config.NewDefaultConfig()
+	*c.QuarantineDir = quarantineDir
+	c.GetLogger().SetOutput(io.Discard)
+
+	file := newTestFileMatch(t, filepath.Join(baseDir, "search", "a.tmp"), time.Now())
+	if _, err := Quarantine(file, &c); err != nil {
+		t.Fatalf("Quarantine() failed: %s", err)
+	}
+
+	// A later run evaluating file age as of a future time must not purge
+	// the run before its retention period has passed.
+	next := config.NewDefaultConfig()
+	*next.QuarantineDir = quarantineDir
+	*next.QuarantineDays = 7
+	*next.AsOf = time.Now().AddDate(0, 0, 30).UTC().Format(time.RFC3339)
+	*next.Remove = true
+	next.GetLogger().SetOutput(io.Discard)
+
+	purged, err := Purge(&next)
+	if err != nil {
+		t.Fatalf("Purge() failed: %s", err)
+	}
+
+	if len(purged) != 0 {
+		t.Errorf("got purged runs %v, want none", purged)
+	}
+
+	if _, err := os.Stat(RunDir(quarantineDir, c.GetRunID())); err != nil {
+		t.Errorf("quarantine run removed: %s", err)
+	}
+}
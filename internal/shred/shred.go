@@ -0,0 +1,159 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shred overwrites the content of files before removing them so
+// that the content cannot be recovered by reading the freed blocks.
+package shred
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/unlink"
+)
+
+// bufferSize is the size of the buffer used when overwriting file content.
+const bufferSize = 64 * 1024
+
+// File overwrites the content of the file at the specified path with random
+// data the specified number of times, flushing the file to disk after each
+// pass. The file is then truncated, renamed to a random name within the same
+// directory to obscure the original name and finally removed. The file is
+// opened, renamed and removed relative to its parent directory, opened below
+// root in the same way as by unlink.File, and only once confirmed to be a
+// regular file matching the provided identity. unlink.ErrChanged is returned
+// (wrapped) if the file or a directory leading to it changed.
+//
+// Overwriting in place offers no guarantee on copy-on-write or journaling
+// filesystems, or on storage which remaps blocks (e.g., SSDs). Other hard
+// links to the file share the overwritten content.
+func File(root string, path string, expected matches.Identity, passes int) error {
+
+	parent, err := unlink.OpenParent(root, path)
+	if err != nil {
+		return err
+	}
+	defer parent.Close()
+
+	fh, err := parent.Open(os.O_WRONLY, expected)
+	if err != nil {
+		return err
+	}
+
+	info, err := fh.Stat()
+	if err != nil {
+		_ = fh.Close()
+		return err
+	}
+
+	if err := overwrite(fh, info.Size(), passes); err != nil {
+		_ = fh.Close()
+		return fmt.Errorf("unable to overwrite %s: %w", path, err)
+	}
+
+	if err := fh.Truncate(0); err != nil {
+		_ = fh.Close()
+		return fmt.Errorf("unable to truncate %s: %w", path, err)
+	}
+
+	if err := fh.Sync(); err != nil {
+		_ = fh.Close()
+		return fmt.Errorf("unable to sync %s: %w", path, err)
+	}
+
+	// Record the shredded file so that it is the file renamed and removed.
+	info, err = fh.Stat()
+	if err != nil {
+		_ = fh.Close()
+		return err
+	}
+	shredded := matches.NewIdentity(info)
+
+	if err := fh.Close(); err != nil {
+		return err
+	}
+
+	if err := obscure(parent, shredded); err != nil {
+		return fmt.Errorf("unable to rename %s: %w", path, err)
+	}
+
+	if err := parent.Check(shredded); err != nil {
+		return err
+	}
+
+	if err := parent.Remove(); err != nil {
+		return fmt.Errorf("unable to remove %s (renamed to %s): %w", path, parent.Path(), err)
+	}
+
+	return nil
+}
+
+// overwrite writes size bytes of random data to the start of the file the
+// specified number of times, flushing the file to disk after each pass.
+func overwrite(fh *os.File, size int64, passes int) error {
+
+	buf := make([]byte, bufferSize)
+
+	for pass := 1; pass <= passes; pass++ {
+
+		if _, err := fh.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		for remaining := size; remaining > 0; {
+			chunk := buf
+			if remaining < int64(len(chunk)) {
+				chunk = chunk[:remaining]
+			}
+
+			if _, err := rand.Read(chunk); err != nil {
+				return err
+			}
+
+			n, err := fh.Write(chunk)
+			if err != nil {
+				return err
+			}
+			remaining -= int64(n)
+		}
+
+		if err := fh.Sync(); err != nil {
+			return fmt.Errorf("pass %d: %w", pass, err)
+		}
+	}
+
+	return nil
+}
+
+// obscure renames the shredded file to a random name within the same
+// directory once confirmed to match the provided identity.
+func obscure(parent *unlink.Parent, shredded matches.Identity) error {
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return err
+	}
+
+	if err := parent.Check(shredded); err != nil {
+		return err
+	}
+
+	return parent.Rename(hex.EncodeToString(name))
+}
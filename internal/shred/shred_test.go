@@ -0,0 +1,139 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shred
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/unlink"
+)
+
+// identity returns the identity of the file at the specified path.
+func identity(t *testing.T, path string) matches.Identity {
+	t.Helper()
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return matches.NewIdentity(info)
+}
+
+func TestFile(t *testing.T) {
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "customers.csv")
+	content := bytes.Repeat([]byte("customer data\n"), 10000)
+
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := File(dir, path, identity(t, path), 3); err != nil {
+		t.Fatalf("File() failed: %s", err)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file %s still present after shredding", path)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 0 {
+		t.Errorf("got %d entries left behind in %s, want 0", len(entries), dir)
+	}
+}
+
+func TestOverwrite(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "customers.csv")
+	content := bytes.Repeat([]byte("customer data\n"), 10000)
+
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fh, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := overwrite(fh, int64(len(content)), 2); err != nil {
+		t.Fatalf("overwrite() failed: %s", err)
+	}
+
+	if err := fh.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != len(content) {
+		t.Errorf("got size %d after overwrite, want %d", len(got), len(content))
+	}
+
+	if bytes.Contains(got, []byte("customer data")) {
+		t.Error("original content still present after overwrite")
+	}
+}
+
+func TestFileRefusesSymlink(t *testing.T) {
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.csv")
+	path := filepath.Join(dir, "customers.csv")
+
+	for _, file := range []string{target, path} {
+		if err := os.WriteFile(file, []byte("customer data"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Replace the file found with a symlink to another file.
+	id := identity(t, path)
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		t.Skipf("unable to create symlink: %s", err)
+	}
+
+	if err := File(dir, path, id, 1); !errors.Is(err, unlink.ErrChanged) {
+		t.Errorf("File() returned %v for file replaced by symlink, want %v", err, unlink.ErrChanged)
+	}
+
+	got, err := os.ReadFile(target)
+	if err != nil || string(got) != "customer data" {
+		t.Errorf("symlink target modified: %q, %v", got, err)
+	}
+
+	if _, err := os.Lstat(path); err != nil {
+		t.Errorf("symlink removed: %s", err)
+	}
+}
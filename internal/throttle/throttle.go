@@ -0,0 +1,161 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package throttle limits the rate at which files are examined and removed so
+// that pruning busy filesystems does not starve other workloads of I/O.
+package throttle
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxDelay is the longest time a single operation may delay the next one,
+// e.g., when removing a very large file with a low limit on bytes removed
+// per second.
+const maxDelay = time.Hour
+
+// Limiter spaces out operations so that no more than the configured number
+// of units (e.g., files or bytes) are processed per second on average. The
+// first operation is not delayed; each operation delays the next one by the
+// time needed to process its units at the configured rate. A nil Limiter
+// does not limit operations.
+type Limiter struct {
+	mu        sync.Mutex
+	perSecond float64
+	next      time.Time
+}
+
+// NewLimiter returns a Limiter allowing the specified number of units per
+// second, or nil if the rate is not positive.
+func NewLimiter(perSecond int) *Limiter {
+
+	if perSecond <= 0 {
+		return nil
+	}
+
+	return &Limiter{perSecond: float64(perSecond)}
+}
+
+// Wait blocks until the next operation may start and reserves the time
+// needed to process the specified number of units. The time spent waiting
+// is returned.
+func (l *Limiter) Wait(units int64) time.Duration {
+
+	if l == nil {
+		return 0
+	}
+
+	if units < 1 {
+		units = 1
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.duration(units))
+	l.mu.Unlock()
+
+	delay := start.Sub(now)
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	return delay
+}
+
+// duration returns the time needed to process the specified number of units,
+// limited to maxDelay.
+func (l *Limiter) duration(units int64) time.Duration {
+
+	seconds := float64(units) / l.perSecond
+	if seconds > maxDelay.Seconds() {
+		return maxDelay
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Throttle holds the limiters applied to a single application run and
+// records the total time spent waiting on them. A nil Throttle does not
+// limit operations.
+type Throttle struct {
+	remove      *Limiter
+	removeBytes *Limiter
+	stat        *Limiter
+
+	// Total time spent waiting, in nanoseconds.
+	waited int64
+}
+
+// New returns a Throttle limiting the number of files removed per second,
+// the number of bytes removed per second and the number of files examined
+// per second while searching paths. A rate of 0 disables the respective
+// limit. nil is returned if all limits are disabled.
+func New(removeRate int, removeBytesRate int, statRate int) *Throttle {
+
+	if removeRate <= 0 && removeBytesRate <= 0 && statRate <= 0 {
+		return nil
+	}
+
+	return &Throttle{
+		remove:      NewLimiter(removeRate),
+		removeBytes: NewLimiter(removeBytesRate),
+		stat:        NewLimiter(statRate),
+	}
+}
+
+// Remove blocks until a file of the specified size may be removed.
+func (t *Throttle) Remove(size int64) {
+
+	if t == nil {
+		return
+	}
+
+	t.record(t.remove.Wait(1))
+	t.record(t.removeBytes.Wait(size))
+}
+
+// Stat blocks until a file may be examined.
+func (t *Throttle) Stat() {
+
+	if t == nil {
+		return
+	}
+
+	t.record(t.stat.Wait(1))
+}
+
+// Waited returns the total time spent waiting on the limits. Time spent
+// waiting concurrently (e.g., by multiple workers) is counted for each
+// waiting worker.
+func (t *Throttle) Waited() time.Duration {
+
+	if t == nil {
+		return 0
+	}
+
+	return time.Duration(atomic.LoadInt64(&t.waited))
+}
+
+// record adds the specified delay to the total time spent waiting.
+func (t *Throttle) record(delay time.Duration) {
+	atomic.AddInt64(&t.waited, int64(delay))
+}
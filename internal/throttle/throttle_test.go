@@ -0,0 +1,94 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		perSecond int
+		units     []int64
+		minimum   time.Duration
+	}{
+		{
+			name:      "files",
+			perSecond: 100,
+			units:     []int64{1, 1, 1, 1, 1, 1},
+			minimum:   50 * time.Millisecond,
+		},
+		{
+			name:      "bytes",
+			perSecond: 1000,
+			units:     []int64{100, 100, 1},
+			minimum:   200 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewLimiter(tt.perSecond)
+
+			start := time.Now()
+			for _, units := range tt.units {
+				limiter.Wait(units)
+			}
+
+			if elapsed := time.Since(start); elapsed < tt.minimum {
+				t.Errorf("limiter waited %s, want at least %s", elapsed, tt.minimum)
+			}
+		})
+	}
+}
+
+func TestThrottleDisabled(t *testing.T) {
+
+	throttle := New(0, 0, 0)
+	if throttle != nil {
+		t.Fatalf("got throttle %+v for disabled limits, want nil", throttle)
+	}
+
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		throttle.Remove(1 << 20)
+		throttle.Stat()
+	}
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond || throttle.Waited() != 0 {
+		t.Errorf("disabled throttle waited %s", elapsed)
+	}
+}
+
+func TestThrottleWaited(t *testing.T) {
+
+	throttle := New(0, 0, 50)
+
+	for i := 0; i < 4; i++ {
+		throttle.Stat()
+	}
+	throttle.Remove(1 << 20)
+
+	// The three throttled lookups take 60ms at 50 per second. Oversleeping
+	// shortens later waits, so only half of that is required.
+	if waited := throttle.Waited(); waited < 30*time.Millisecond {
+		t.Errorf("got %s spent waiting, want at least %s", waited, 30*time.Millisecond)
+	}
+}
@@ -0,0 +1,99 @@
+//go:build linux
+// +build linux
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package truncate
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// fileID identifies a file by device and inode number.
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// OpenFiles records the files held open by running processes.
+type OpenFiles struct {
+	files map[fileID]struct{}
+}
+
+// newFileID returns the device and inode number of the file, if available.
+func newFileID(info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(stat.Dev), ino: stat.Ino}, true // nolint:unconvert
+}
+
+// LoadOpenFiles collects the files held open by running processes from the
+// file descriptors listed below /proc. Processes owned by other users are
+// only visible with sufficient privileges.
+func LoadOpenFiles() (*OpenFiles, error) {
+
+	fdDirs, err := filepath.Glob("/proc/[0-9]*/fd")
+	if err != nil {
+		return nil, err
+	}
+
+	openFiles := OpenFiles{
+		files: make(map[fileID]struct{}),
+	}
+
+	for _, fdDir := range fdDirs {
+
+		// Processes may exit or be inaccessible; skip them.
+		entries, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			info, err := os.Stat(filepath.Join(fdDir, entry.Name()))
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if id, ok := newFileID(info); ok {
+				openFiles.files[id] = struct{}{}
+			}
+		}
+	}
+
+	return &openFiles, nil
+}
+
+// IsOpen indicates whether the specified file is held open by a running
+// process.
+func (of *OpenFiles) IsOpen(info os.FileInfo) bool {
+
+	if of == nil {
+		return false
+	}
+
+	id, ok := newFileID(info)
+	if !ok {
+		return false
+	}
+
+	_, open := of.files[id]
+	return open
+}
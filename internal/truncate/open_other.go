@@ -0,0 +1,39 @@
+//go:build !linux
+// +build !linux
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package truncate
+
+import (
+	"os"
+)
+
+// OpenFiles records the files held open by running processes.
+type OpenFiles struct{}
+
+// LoadOpenFiles is not supported on this platform and always returns
+// ErrNotSupported.
+func LoadOpenFiles() (*OpenFiles, error) {
+	return nil, ErrNotSupported
+}
+
+// IsOpen always returns false as open files cannot be detected on this
+// platform.
+func (of *OpenFiles) IsOpen(_ os.FileInfo) bool {
+	return false
+}
@@ -0,0 +1,170 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package truncate reclaims the space used by files without removing them,
+// optionally keeping the end of each file. This is useful for files which
+// are still held open by the process writing them, as removing such files
+// does not free their space until the process closes them.
+package truncate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/unlink"
+)
+
+// ErrNotSupported indicates that detecting open files is not supported on
+// the current platform.
+var ErrNotSupported = errors.New("open file detection not supported on this platform")
+
+// bufferSize is the size of the buffer used when searching for line breaks.
+const bufferSize = 64 * 1024
+
+// File truncates the file at the specified path, keeping the last keepBytes
+// bytes or, if keepLines is non-zero, the last keepLines lines. The kept
+// content is moved to the start of the file. The file is opened relative to
+// its parent directory, opened below root in the same way as by unlink.File,
+// and only once confirmed to be a regular file matching the provided
+// identity; unlink.ErrChanged is returned (wrapped) otherwise. The number of
+// bytes reclaimed is returned.
+//
+// Content appended by a writer while the file is being truncated may be
+// lost. Writers which did not open the file in append mode continue to
+// write at their previous offset, leaving a sparse file behind.
+func File(root string, path string, expected matches.Identity, keepBytes int64, keepLines int) (int64, error) {
+
+	parent, err := unlink.OpenParent(root, path)
+	if err != nil {
+		return 0, err
+	}
+	defer parent.Close()
+
+	fh, err := parent.Open(os.O_RDWR, expected)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := fh.Stat()
+	if err != nil {
+		_ = fh.Close()
+		return 0, err
+	}
+
+	size := info.Size()
+
+	keepFrom := size - keepBytes
+	if keepLines > 0 {
+		keepFrom, err = lineOffset(fh, size, keepLines)
+		if err != nil {
+			_ = fh.Close()
+			return 0, fmt.Errorf("unable to find last %d lines of %s: %w", keepLines, path, err)
+		}
+	}
+	if keepFrom < 0 {
+		keepFrom = 0
+	}
+
+	kept := size - keepFrom
+	if kept > 0 && keepFrom > 0 {
+		if err := shift(fh, keepFrom, kept); err != nil {
+			_ = fh.Close()
+			return 0, fmt.Errorf("unable to keep end of %s: %w", path, err)
+		}
+	}
+
+	if err := fh.Truncate(kept); err != nil {
+		_ = fh.Close()
+		return 0, err
+	}
+
+	if err := fh.Sync(); err != nil {
+		_ = fh.Close()
+		return 0, err
+	}
+
+	if err := fh.Close(); err != nil {
+		return 0, err
+	}
+
+	return size - kept, nil
+}
+
+// lineOffset returns the offset of the first of the last n lines of the
+// file. A trailing line break does not start a new line.
+func lineOffset(fh *os.File, size int64, n int) (int64, error) {
+
+	buf := make([]byte, bufferSize)
+	end := size
+	found := 0
+
+	for end > 0 {
+		start := end - int64(len(buf))
+		if start < 0 {
+			start = 0
+		}
+		chunk := buf[:end-start]
+
+		if _, err := fh.ReadAt(chunk, start); err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' || start+int64(i) == size-1 {
+				continue
+			}
+			found++
+			if found == n {
+				return start + int64(i) + 1, nil
+			}
+		}
+
+		end = start
+	}
+
+	return 0, nil
+}
+
+// shift moves length bytes starting at offset to the start of the file.
+func shift(fh *os.File, offset int64, length int64) error {
+
+	buf := make([]byte, bufferSize)
+
+	for copied := int64(0); copied < length; {
+		chunk := buf
+		if remaining := length - copied; remaining < int64(len(chunk)) {
+			chunk = chunk[:remaining]
+		}
+
+		n, err := fh.ReadAt(chunk, offset+copied)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if n == 0 {
+			return io.ErrUnexpectedEOF
+		}
+
+		if _, err := fh.WriteAt(chunk[:n], copied); err != nil {
+			return err
+		}
+		copied += int64(n)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package truncate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/atc0005/elbow/internal/matches"
+	"github.com/atc0005/elbow/internal/unlink"
+)
+
+// identity returns the identity of the file at the specified path.
+func identity(t *testing.T, path string) matches.Identity {
+	t.Helper()
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return matches.NewIdentity(info)
+}
+
+func TestFile(t *testing.T) {
+
+	content := "line 1\nline 2\nline 3\nline 4\n"
+
+	tests := []struct {
+		name      string
+		content   string
+		keepBytes int64
+		keepLines int
+		want      string
+	}{
+		{name: "truncate entirely", content: content, want: ""},
+		{name: "keep last bytes", content: content, keepBytes: 7, want: "line 4\n"},
+		{name: "keep more bytes than size", content: content, keepBytes: 1024, want: content},
+		{name: "keep last lines", content: content, keepLines: 2, want: "line 3\nline 4\n"},
+		{name: "keep last lines without trailing break", content: "line 1\nline 2\nline 3", keepLines: 1, want: "line 3"},
+		{name: "keep more lines than present", content: content, keepLines: 10, want: content},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "app.log")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			reclaimed, err := File(dir, path, identity(t, path), tt.keepBytes, tt.keepLines)
+			if err != nil {
+				t.Fatalf("File() failed: %s", err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("got content %q, want %q", got, tt.want)
+			}
+
+			if want := int64(len(tt.content) - len(tt.want)); reclaimed != want {
+				t.Errorf("got %d bytes reclaimed, want %d", reclaimed, want)
+			}
+		})
+	}
+}
+
+func TestFileChanged(t *testing.T) {
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(path, []byte("line 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	id := identity(t, path)
+
+	appended := "line 1\nline 2\n"
+	if err := os.WriteFile(path, []byte(appended), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := File(dir, path, id, 0, 0); !errors.Is(err, unlink.ErrChanged) {
+		t.Fatalf("File() returned %v for rewritten file, want %v", err, unlink.ErrChanged)
+	}
+
+	if got, err := os.ReadFile(path); err != nil || string(got) != appended {
+		t.Fatalf("rewritten file modified: %q, %v", got, err)
+	}
+
+	// Content written since the file was found is ignored when only the
+	// file itself is identified.
+	if _, err := File(dir, path, id.SameFile(), 0, 0); err != nil {
+		t.Fatalf("File() failed for file written since it was found: %s", err)
+	}
+
+	if got, err := os.ReadFile(path); err != nil || len(got) != 0 {
+		t.Errorf("got content %q, want empty file", got)
+	}
+}
+
+func TestIsOpen(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	fh, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+
+	openFiles, err := LoadOpenFiles()
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !openFiles.IsOpen(info) {
+		t.Errorf("file %s held open by test not detected as open", path)
+	}
+
+	closedPath := filepath.Join(t.TempDir(), "closed.log")
+	if err := os.WriteFile(closedPath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	closedInfo, err := os.Stat(closedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if openFiles.IsOpen(closedInfo) {
+		t.Errorf("closed file %s detected as open", closedPath)
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package unlink removes files only after confirming that they are the same
+// files found when searching a path. Files can be replaced, rotated or
+// rewritten between searching a path and removing them, and a directory
+// below the path can be swapped for a symlink to redirect the removal
+// elsewhere (e.g., in a shared directory). Actions which open, rename or
+// move files instead of removing them do so through the verified parent
+// directory of each file in the same way.
+package unlink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atc0005/elbow/internal/matches"
+)
+
+// ErrChanged indicates that a file (or a directory leading to it) changed
+// since the file was found and the file was therefore not removed.
+var ErrChanged = errors.New("file changed since it was found")
+
+// Parent is the parent directory of a file found when searching a path,
+// opened one directory at a time from a trusted root without following
+// symlinks. The file is examined, opened, renamed and removed relative to
+// the open directory, so that none of these operations can be redirected by
+// swapping a directory for a symlink. Windows does not provide
+// directory-relative file operations, so the file is accessed by path there.
+type Parent struct {
+	handle
+
+	// name of the file within the directory.
+	name string
+
+	// path of the file, as provided when opening the directory.
+	path string
+}
+
+// OpenParent opens the parent directory of the file at the specified path.
+// The root directory itself is trusted as it is provided by the operator. If
+// root is empty or the file is not located below it, the filesystem root is
+// used instead. ErrChanged is returned (wrapped) if a directory between root
+// and the file is no longer a directory. The caller must close the returned
+// Parent.
+func OpenParent(root string, path string) (*Parent, error) {
+	return openParent(root, path)
+}
+
+// Close closes the parent directory.
+func (p *Parent) Close() error {
+	return p.close()
+}
+
+// Check confirms that the file still matches the provided identity.
+// ErrChanged is returned (wrapped) if it does not.
+func (p *Parent) Check(expected matches.Identity) error {
+	return p.check(expected)
+}
+
+// Open opens the file using the provided flags (e.g., os.O_RDONLY) without
+// following symlinks and confirms that the opened file is a regular file
+// matching the provided identity. ErrChanged is returned (wrapped) if it is
+// not; the file is then left closed.
+func (p *Parent) Open(flag int, expected matches.Identity) (*os.File, error) {
+	return p.open(flag, expected)
+}
+
+// Rename renames the file to the specified name within the same directory.
+// An existing file with that name is never replaced.
+func (p *Parent) Rename(name string) error {
+
+	if err := p.rename(name); err != nil {
+		return err
+	}
+
+	p.name = name
+	p.path = filepath.Join(filepath.Dir(p.path), name)
+
+	return nil
+}
+
+// RenameTo moves the file to the specified destination path. The
+// destination is not resolved relative to the parent directory, so it is
+// expected to be a path controlled by the operator (e.g., the quarantine
+// directory). The error returned by the underlying system call is wrapped,
+// so that callers can detect moves across filesystems.
+func (p *Parent) RenameTo(destination string) error {
+	return p.renameTo(destination)
+}
+
+// Remove removes the file.
+func (p *Parent) Remove() error {
+	return p.remove()
+}
+
+// Path returns the path of the file, reflecting any rename.
+func (p *Parent) Path() string {
+	return p.path
+}
+
+// File removes the file at the specified path after confirming that it still
+// matches the provided identity. The file is examined and removed relative
+// to its parent directory (see Parent), so that neither can be redirected by
+// swapping a directory for a symlink. The root directory itself is trusted
+// as it is provided by the operator. If root is empty or the file is not
+// located below it, the filesystem root is used instead.
+//
+// ErrChanged is returned (wrapped) if the file or a directory leading to it
+// changed; the file is left in place.
+func File(root string, path string, expected matches.Identity) error {
+
+	parent, err := OpenParent(root, path)
+	if err != nil {
+		return err
+	}
+	defer parent.Close()
+
+	if err := parent.Check(expected); err != nil {
+		return err
+	}
+
+	return parent.Remove()
+}
+
+// Verify confirms that the file at the specified path still matches the
+// provided identity in the same way as File, without removing it.
+// ErrChanged is returned (wrapped) if the file or a directory leading to it
+// changed.
+func Verify(root string, path string, expected matches.Identity) error {
+
+	parent, err := OpenParent(root, path)
+	if err != nil {
+		return err
+	}
+	defer parent.Close()
+
+	return parent.Check(expected)
+}
+
+// split returns the absolute form of the trusted root directory along with
+// the directories between it and the file at the specified path and the
+// name of the file.
+func split(root string, path string) (string, []string, string, error) {
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	absRoot := ""
+	if root != "" {
+		absRoot, err = filepath.Abs(root)
+		if err != nil {
+			return "", nil, "", err
+		}
+	}
+
+	rel, err := filepath.Rel(absRoot, absPath)
+	if absRoot == "" || err != nil || rel == ".." ||
+		strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		absRoot = filepath.VolumeName(absPath) + string(filepath.Separator)
+		rel, err = filepath.Rel(absRoot, absPath)
+		if err != nil {
+			return "", nil, "", err
+		}
+	}
+
+	var dirs []string
+	if dir := filepath.Dir(rel); dir != "." {
+		dirs = strings.Split(dir, string(filepath.Separator))
+	}
+
+	return absRoot, dirs, filepath.Base(rel), nil
+}
+
+// changed returns an error wrapping ErrChanged for the file at the
+// specified path.
+func changed(path string, reason string) error {
+	return fmt.Errorf("%w: %s: %s", ErrChanged, matches.DisplayName(path), reason)
+}
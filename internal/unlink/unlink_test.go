@@ -0,0 +1,175 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unlink
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/atc0005/elbow/internal/matches"
+)
+
+// newFile creates a file below the specified directory and returns its
+// path along with its identity.
+func newFile(t *testing.T, dir string, name string) (string, matches.Identity) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("unlink test\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path, matches.NewIdentity(info)
+}
+
+func TestFile(t *testing.T) {
+
+	tests := []struct {
+		name string
+
+		// modify changes the file (or a directory leading to it) after its
+		// identity was recorded.
+		modify func(t *testing.T, root string, path string)
+
+		// unixOnly indicates that the change can only be detected on
+		// platforms exposing inode numbers and symlinks.
+		unixOnly bool
+
+		wantChanged bool
+	}{
+		{
+			name:   "unchanged",
+			modify: func(t *testing.T, root string, path string) {},
+		},
+		{
+			name: "rewritten",
+			modify: func(t *testing.T, root string, path string) {
+				if err := os.WriteFile(path, []byte("rewritten with new content\n"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantChanged: true,
+		},
+		{
+			name: "replaced",
+			modify: func(t *testing.T, root string, path string) {
+				info, err := os.Lstat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				replacement := path + ".new"
+				if err := os.WriteFile(replacement, []byte("unlink test\n"), 0600); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(replacement, info.ModTime(), info.ModTime()); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Rename(replacement, path); err != nil {
+					t.Fatal(err)
+				}
+			},
+			unixOnly:    true,
+			wantChanged: true,
+		},
+		{
+			name: "directory swapped for symlink",
+			modify: func(t *testing.T, root string, path string) {
+				dir := filepath.Join(root, "sub")
+				moved := filepath.Join(root, "moved")
+				if err := os.Rename(dir, moved); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Symlink(moved, dir); err != nil {
+					t.Fatal(err)
+				}
+			},
+			unixOnly:    true,
+			wantChanged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if tt.unixOnly && runtime.GOOS == "windows" {
+				t.Skip("not detectable on Windows")
+			}
+
+			root := t.TempDir()
+			path, id := newFile(t, filepath.Join(root, "sub", "dir"), "app.log")
+
+			tt.modify(t, root, path)
+
+			err := File(root, path, id)
+
+			switch {
+			case tt.wantChanged && !errors.Is(err, ErrChanged):
+				t.Fatalf("File() returned %v, want %v", err, ErrChanged)
+			case !tt.wantChanged && err != nil:
+				t.Fatalf("File() failed: %s", err)
+			}
+
+			_, statErr := os.Lstat(path)
+			if removed := errors.Is(statErr, os.ErrNotExist); removed == tt.wantChanged {
+				t.Errorf("file removed: %t, want %t", removed, !tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestFileTrustsRoot(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on Windows")
+	}
+
+	dir := t.TempDir()
+	realRoot := filepath.Join(dir, "real")
+	root := filepath.Join(dir, "link")
+
+	path, id := newFile(t, filepath.Join(realRoot, "sub"), "app.log")
+	if err := os.Symlink(realRoot, root); err != nil {
+		t.Fatal(err)
+	}
+
+	linkedPath := filepath.Join(root, "sub", filepath.Base(path))
+
+	if err := Verify(root, linkedPath, id); err != nil {
+		t.Fatalf("Verify() failed for file below symlinked root: %s", err)
+	}
+
+	if err := File(root, linkedPath, id); err != nil {
+		t.Fatalf("File() failed for file below symlinked root: %s", err)
+	}
+
+	if _, err := os.Lstat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file %s still present after File()", path)
+	}
+}
@@ -0,0 +1,176 @@
+//go:build !windows
+// +build !windows
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unlink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/atc0005/elbow/internal/matches"
+	"golang.org/x/sys/unix"
+)
+
+// handle holds the file descriptor of an open parent directory.
+type handle struct {
+	fd int
+}
+
+// openParent opens the parent directory of the file at the specified path,
+// descending from the trusted root directory one directory at a time without
+// following symlinks.
+func openParent(root string, path string) (*Parent, error) {
+
+	root, dirs, name, err := split(root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := unix.Open(root, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error opening directory %s: %w", root, err)
+	}
+
+	for _, dir := range dirs {
+		next, err := unix.Openat(fd, dir, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		_ = unix.Close(fd)
+
+		switch {
+		// A symlink (ELOOP) or other file (ENOTDIR) has taken the place of
+		// the directory.
+		case errors.Is(err, unix.ELOOP), errors.Is(err, unix.ENOTDIR):
+			return nil, changed(path, fmt.Sprintf("directory %q is no longer a directory", dir))
+		case err != nil:
+			return nil, fmt.Errorf("error opening directory %q of %s: %w", dir, matches.DisplayName(path), err)
+		}
+
+		fd = next
+	}
+
+	return &Parent{handle: handle{fd: fd}, name: name, path: path}, nil
+}
+
+// close closes the file descriptor of the parent directory.
+func (p *Parent) close() error {
+	return unix.Close(p.fd)
+}
+
+// identity returns the identity of the file described by the provided
+// metadata.
+func identity(stat *unix.Stat_t) matches.Identity {
+	return matches.Identity{
+		Device:  uint64(stat.Dev), // nolint:unconvert
+		Inode:   stat.Ino,
+		Size:    stat.Size,
+		ModTime: time.Unix(stat.Mtim.Unix()),
+	}
+}
+
+// check examines the file relative to the parent directory, without
+// following symlinks, and compares it against the expected identity.
+func (p *Parent) check(expected matches.Identity) error {
+
+	var stat unix.Stat_t
+	if err := unix.Fstatat(p.fd, p.name, &stat, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return fmt.Errorf("error examining file %s: %w", matches.DisplayName(p.path), err)
+	}
+
+	if reason := expected.Compare(identity(&stat)); reason != "" {
+		return changed(p.path, reason)
+	}
+
+	return nil
+}
+
+// open opens the file relative to the parent directory without following
+// symlinks and compares the opened file against the expected identity.
+func (p *Parent) open(flag int, expected matches.Identity) (*os.File, error) {
+
+	// Opening without blocking guards against a FIFO having taken the place
+	// of the file; it is switched back to blocking once confirmed.
+	fd, err := unix.Openat(p.fd, p.name, flag|unix.O_NOFOLLOW|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	switch {
+	case errors.Is(err, unix.ELOOP):
+		return nil, changed(p.path, "file replaced by a symlink")
+	case err != nil:
+		return nil, fmt.Errorf("error opening file %s: %w", matches.DisplayName(p.path), err)
+	}
+
+	var stat unix.Stat_t
+	if err := unix.Fstat(fd, &stat); err != nil {
+		_ = unix.Close(fd)
+		return nil, fmt.Errorf("error examining file %s: %w", matches.DisplayName(p.path), err)
+	}
+
+	if reason := expected.Compare(identity(&stat)); reason != "" {
+		_ = unix.Close(fd)
+		return nil, changed(p.path, reason)
+	}
+
+	if stat.Mode&unix.S_IFMT != unix.S_IFREG {
+		_ = unix.Close(fd)
+		return nil, changed(p.path, "not a regular file")
+	}
+
+	if err := unix.SetNonblock(fd, false); err != nil {
+		_ = unix.Close(fd)
+		return nil, fmt.Errorf("error opening file %s: %w", matches.DisplayName(p.path), err)
+	}
+
+	return os.NewFile(uintptr(fd), p.path), nil
+}
+
+// rename renames the file relative to the parent directory, unless a file
+// with the new name already exists.
+func (p *Parent) rename(name string) error {
+
+	var stat unix.Stat_t
+	if err := unix.Fstatat(p.fd, name, &stat, unix.AT_SYMLINK_NOFOLLOW); err == nil {
+		return fmt.Errorf("error renaming file %s: %s already exists", matches.DisplayName(p.path), name)
+	}
+
+	if err := unix.Renameat(p.fd, p.name, p.fd, name); err != nil {
+		return fmt.Errorf("error renaming file %s: %w", matches.DisplayName(p.path), err)
+	}
+
+	return nil
+}
+
+// renameTo moves the file from the parent directory to the destination
+// path.
+func (p *Parent) renameTo(destination string) error {
+
+	if err := unix.Renameat(p.fd, p.name, unix.AT_FDCWD, destination); err != nil {
+		return &os.LinkError{Op: "rename", Old: p.path, New: destination, Err: err}
+	}
+
+	return nil
+}
+
+// remove removes the file relative to the parent directory.
+func (p *Parent) remove() error {
+
+	if err := unix.Unlinkat(p.fd, p.name, 0); err != nil {
+		return fmt.Errorf("error removing file %s: %w", matches.DisplayName(p.path), err)
+	}
+
+	return nil
+}
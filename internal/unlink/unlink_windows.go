@@ -0,0 +1,123 @@
+//go:build windows
+// +build windows
+
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/elbow
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unlink
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/atc0005/elbow/internal/matches"
+)
+
+// handle is empty on Windows, which does not provide directory-relative file
+// operations; the file is accessed by path and identified by size and
+// modification time only.
+type handle struct{}
+
+// openParent returns the parent directory of the file at the specified
+// path. Directories are not opened on Windows.
+func openParent(_ string, path string) (*Parent, error) {
+	return &Parent{name: filepath.Base(path), path: path}, nil
+}
+
+// close is a no-op on Windows.
+func (p *Parent) close() error {
+	return nil
+}
+
+// check examines the file by path, without following symlinks, and compares
+// it against the expected identity.
+func (p *Parent) check(expected matches.Identity) error {
+
+	info, err := os.Lstat(p.path)
+	if err != nil {
+		return fmt.Errorf("error examining file %s: %w", matches.DisplayName(p.path), err)
+	}
+
+	if reason := expected.Compare(matches.NewIdentity(info)); reason != "" {
+		return changed(p.path, reason)
+	}
+
+	return nil
+}
+
+// open opens the file by path once confirmed to match the expected identity
+// and compares the opened file against it once more.
+func (p *Parent) open(flag int, expected matches.Identity) (*os.File, error) {
+
+	if err := p.check(expected); err != nil {
+		return nil, err
+	}
+
+	fh, err := os.OpenFile(filepath.Clean(p.path), flag, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %w", matches.DisplayName(p.path), err)
+	}
+
+	info, err := fh.Stat()
+	if err != nil {
+		_ = fh.Close()
+		return nil, fmt.Errorf("error examining file %s: %w", matches.DisplayName(p.path), err)
+	}
+
+	if reason := expected.Compare(matches.NewIdentity(info)); reason != "" {
+		_ = fh.Close()
+		return nil, changed(p.path, reason)
+	}
+
+	if !info.Mode().IsRegular() {
+		_ = fh.Close()
+		return nil, changed(p.path, "not a regular file")
+	}
+
+	return fh, nil
+}
+
+// rename renames the file by path, unless a file with the new name already
+// exists.
+func (p *Parent) rename(name string) error {
+
+	newPath := filepath.Join(filepath.Dir(p.path), name)
+	if _, err := os.Lstat(newPath); err == nil {
+		return fmt.Errorf("error renaming file %s: %s already exists", matches.DisplayName(p.path), name)
+	}
+
+	if err := os.Rename(p.path, newPath); err != nil {
+		return fmt.Errorf("error renaming file %s: %w", matches.DisplayName(p.path), err)
+	}
+
+	return nil
+}
+
+// renameTo moves the file to the destination path.
+func (p *Parent) renameTo(destination string) error {
+	return os.Rename(p.path, destination)
+}
+
+// remove removes the file by path.
+func (p *Parent) remove() error {
+
+	if err := os.Remove(p.path); err != nil {
+		return fmt.Errorf("error removing file %s: %w", matches.DisplayName(p.path), err)
+	}
+
+	return nil
+}